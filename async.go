@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// If job was not found - JobNotFoundError is returned.
+type JobNotFoundError struct {
+	ID string
+}
+
+func (err *JobNotFoundError) Error() string {
+	return fmt.Sprintf("job %q not found", err.ID)
+}
+
+// If job was cancelled before it finished - JobCancelledError is returned by job status resource.
+type JobCancelledError struct {
+	ID string
+}
+
+func (err *JobCancelledError) Error() string {
+	return fmt.Sprintf("job %q was cancelled", err.ID)
+}
+
+// If job was submitted after JobRunner was closed - JobRunnerClosedError is returned.
+type JobRunnerClosedError struct{}
+
+func (err *JobRunnerClosedError) Error() string {
+	return "job runner is closed"
+}
+
+// Async is handler of long operation that is executed as background job.
+// Input is read from request body as JSON.
+type Async[In, Out any] func(context.Context, In) (Out, error)
+
+// AsyncConfig configures Async handlers.
+type AsyncConfig struct {
+	Runner JobRunner
+	// Location returns URL of job status resource.
+	// Job ID is appended to request path if nil.
+	Location func(r *http.Request, id string) string
+	// JobID reads job ID from status and cancel requests.
+	// "id" path value or last path segment is used if nil.
+	JobID func(*http.Request) string
+}
+
+// AsyncHandlers are endpoints of Async handler.
+type AsyncHandlers struct {
+	// Submit enqueues job and responds with 202 Accepted and Location of job status resource.
+	Submit http.Handler
+	// Status responds with JobStatus while job is in progress,
+	// with job result once it succeeded or with job error once it failed.
+	Status http.Handler
+	// Cancel cancels job and responds with 204 No Content.
+	Cancel http.Handler
+}
+
+// With builds Async handlers that share options.
+func (handle Async[In, Out]) With(config AsyncConfig, opts ...func(Options)) AsyncHandlers {
+	if config.Location == nil {
+		config.Location = func(r *http.Request, id string) string { return path.Join(r.URL.Path, id) }
+	}
+
+	if config.JobID == nil {
+		config.JobID = jobID
+	}
+
+	submitOptions, statusOptions, cancelOptions := newOptions(), newOptions(), newOptions()
+	for _, option := range opts {
+		option(submitOptions)
+		option(statusOptions)
+		option(cancelOptions)
+	}
+
+	submitOptions.successCode = http.StatusAccepted
+	cancelOptions.successCode = http.StatusNoContent
+
+	return AsyncHandlers{
+		Submit: submitOptions.handler(handle.submit(config, submitOptions)),
+		Status: statusOptions.handler(statusOptions.authorized(jobStatus(config))),
+		Cancel: cancelOptions.handler(cancelOptions.authorized(cancelJob(config))),
+	}
+}
+
+func (handle Async[In, Out]) submit(config AsyncConfig, opts *options) handleFunc {
+	return func(r *http.Request) (any, error) {
+		in, err := ReadJSON[In](r)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := opts.authorize(r, *in); err != nil {
+			return nil, err
+		}
+
+		status, err := config.Runner.Submit(
+			context.WithoutCancel(r.Context()),
+			func(ctx context.Context) (any, error) { return handle(ctx, *in) },
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return withHeader{
+			value:  status,
+			header: http.Header{"Location": {config.Location(r, status.ID)}},
+		}, nil
+	}
+}
+
+func jobStatus(config AsyncConfig) handleFunc {
+	return func(r *http.Request) (any, error) {
+		status, err := config.Runner.Status(r.Context(), config.JobID(r))
+		if err != nil {
+			return nil, err
+		}
+
+		switch status.State {
+		case JobSucceeded:
+			return status.Result, nil
+		case JobFailed:
+			return nil, status.Err
+		case JobCancelled:
+			return nil, &JobCancelledError{ID: status.ID}
+		default:
+			return withHeader{value: status, header: http.Header{"Retry-After": {"1"}}}, nil
+		}
+	}
+}
+
+func cancelJob(config AsyncConfig) handleFunc {
+	return func(r *http.Request) (any, error) {
+		return nil, config.Runner.Cancel(r.Context(), config.JobID(r))
+	}
+}
+
+func jobID(r *http.Request) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+
+	return path.Base(strings.TrimSuffix(r.URL.Path, "/"))
+}
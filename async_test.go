@@ -0,0 +1,153 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testReport struct {
+	Rows int `json:"rows"`
+}
+
+var _ = Describe("Async", func() {
+	var (
+		runner *controller.MemoryJobRunner
+		mux    *http.ServeMux
+	)
+
+	BeforeEach(func() {
+		runner = controller.NewMemoryJobRunner(1, 1, time.Minute)
+		mux = http.NewServeMux()
+	})
+
+	AfterEach(func() {
+		runner.Close()
+	})
+
+	register := func(handle controller.Async[testReport, testReport]) {
+		handlers := handle.With(
+			controller.AsyncConfig{Runner: runner},
+			controller.ErrorWithCode[*testError](http.StatusBadRequest),
+		)
+
+		mux.Handle("POST /reports", handlers.Submit)
+		mux.Handle("GET /reports/{id}", handlers.Status)
+		mux.Handle("DELETE /reports/{id}", handlers.Cancel)
+	}
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		return w
+	}
+
+	submit := func() string {
+		w := serve(http.MethodPost, "/reports", `{"rows":3}`)
+
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+
+		var status controller.JobStatus
+
+		Expect(json.Unmarshal(w.Body.Bytes(), &status)).To(Succeed())
+		Expect(w.Header().Get("Location")).To(Equal("/reports/" + status.ID))
+
+		return w.Header().Get("Location")
+	}
+
+	It("returns job result from status resource", func() {
+		release := make(chan struct{})
+		register(func(ctx context.Context, in testReport) (testReport, error) {
+			<-release
+			return testReport{Rows: in.Rows * 2}, nil
+		})
+
+		location := submit()
+
+		w := serve(http.MethodGet, location, "")
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+		Expect(w.Body.String()).To(ContainSubstring(`"state":`))
+
+		close(release)
+
+		Eventually(func() string { return serve(http.MethodGet, location, "").Body.String() }).
+			Should(Equal("{\"rows\":6}\n"))
+	})
+
+	It("returns job error mapped through error handlers", func() {
+		register(func(ctx context.Context, in testReport) (testReport, error) {
+			return testReport{}, &testError{Detail: "too many rows"}
+		})
+
+		location := submit()
+
+		Eventually(func() int { return serve(http.MethodGet, location, "").Code }).
+			Should(Equal(http.StatusBadRequest))
+	})
+
+	It("cancels job", func() {
+		register(func(ctx context.Context, in testReport) (testReport, error) {
+			<-ctx.Done()
+			return testReport{}, ctx.Err()
+		})
+
+		location := submit()
+
+		Expect(serve(http.MethodDelete, location, "").Code).To(Equal(http.StatusNoContent))
+		Expect(serve(http.MethodGet, location, "").Code).To(Equal(http.StatusGone))
+	})
+
+	It("responds with not found for unknown job", func() {
+		register(func(ctx context.Context, in testReport) (testReport, error) {
+			return in, nil
+		})
+
+		Expect(serve(http.MethodGet, "/reports/unknown", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("rejects jobs over queue capacity", func() {
+		release := make(chan struct{})
+		defer close(release)
+
+		register(func(ctx context.Context, in testReport) (testReport, error) {
+			<-release
+			return in, nil
+		})
+
+		location := submit()
+		Eventually(func() string { return serve(http.MethodGet, location, "").Body.String() }).
+			Should(ContainSubstring(`"state":"running"`))
+
+		submit()
+
+		Expect(serve(http.MethodPost, "/reports", `{"rows":3}`).Code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("rejects jobs after runner was closed", func() {
+		register(func(ctx context.Context, in testReport) (testReport, error) {
+			return in, nil
+		})
+
+		runner.Close()
+
+		Expect(serve(http.MethodPost, "/reports", `{"rows":3}`).Code).To(Equal(http.StatusServiceUnavailable))
+
+		_, err := runner.Submit(context.Background(), func(context.Context) (any, error) { return nil, nil })
+
+		var closedErr *controller.JobRunnerClosedError
+
+		Expect(err).To(BeAssignableToTypeOf(closedErr))
+		Expect(runner.Close).NotTo(Panic())
+	})
+})
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// If request can not be authenticated - UnauthorizedError is returned.
+type UnauthorizedError struct {
+	// Challenge is sent in WWW-Authenticate header.
+	Challenge string
+	err       error
+}
+
+func (err *UnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized: %s", err.err)
+}
+
+func (err *UnauthorizedError) Unwrap() error {
+	return err.err
+}
+
+// Authenticator turns request into principal P.
+type Authenticator[P any] func(*http.Request) (P, error)
+
+type principalKey struct{}
+
+// Authenticate authenticates every request with authenticate before handler is called.
+// Handler gets principal with Principal.
+// Errors are handled as handler errors, *UnauthorizedError results in 401 Unauthorized
+// with WWW-Authenticate challenge unless matched otherwise.
+func Authenticate[P any](authenticate Authenticator[P]) func(Options) {
+	return func(o Options) {
+		o.Authenticate(func(r *http.Request) (any, error) { return authenticate(r) })
+	}
+}
+
+// Principal returns principal set for request by Authenticate option.
+func Principal[P any](r *http.Request) (P, bool) {
+	principal, ok := r.Context().Value(principalKey{}).(P)
+	return principal, ok
+}
+
+func (opts *options) authenticateHandler(next http.Handler) http.Handler {
+	if opts.authenticate == nil {
+		return next
+	}
+
+	authenticate := opts.authenticate
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticate(r)
+		if err != nil {
+			var unauthorized *UnauthorizedError
+			if errors.As(err, &unauthorized) && unauthorized.Challenge != "" {
+				w.Header().Set("WWW-Authenticate", unauthorized.Challenge)
+			}
+
+			opts.writeError(w, r, err)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// BearerToken authenticates request with token from "Authorization: Bearer <token>" header.
+// Errors returned by verify are treated as invalid token.
+func BearerToken[P any](verify func(ctx context.Context, token string) (P, error)) Authenticator[P] {
+	return func(r *http.Request) (P, error) {
+		token, ok := bearerToken(r)
+		if !ok {
+			var zero P
+			return zero, &UnauthorizedError{Challenge: "Bearer", err: errors.New("bearer token is missing")}
+		}
+
+		principal, err := verify(r.Context(), token)
+		if err != nil {
+			return principal, &UnauthorizedError{Challenge: `Bearer error="invalid_token"`, err: err}
+		}
+
+		return principal, nil
+	}
+}
+
+// BasicAuth authenticates request with user and password from "Authorization: Basic" header.
+// Errors returned by verify are treated as invalid credentials.
+func BasicAuth[P any](realm string, verify func(ctx context.Context, user, password string) (P, error)) Authenticator[P] {
+	challenge := fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", realm)
+
+	return func(r *http.Request) (P, error) {
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			var zero P
+			return zero, &UnauthorizedError{Challenge: challenge, err: errors.New("basic credentials are missing")}
+		}
+
+		principal, err := verify(r.Context(), user, password)
+		if err != nil {
+			return principal, &UnauthorizedError{Challenge: challenge, err: err}
+		}
+
+		return principal, nil
+	}
+}
+
+// APIKey authenticates request with key from header.
+// Errors returned by verify are treated as invalid key.
+func APIKey[P any](header string, verify func(ctx context.Context, key string) (P, error)) Authenticator[P] {
+	challenge := fmt.Sprintf("APIKey header=%q", header)
+
+	return func(r *http.Request) (P, error) {
+		key := r.Header.Get(header)
+		if key == "" {
+			var zero P
+			return zero, &UnauthorizedError{Challenge: challenge, err: fmt.Errorf("%s header is missing", header)}
+		}
+
+		principal, err := verify(r.Context(), key)
+		if err != nil {
+			return principal, &UnauthorizedError{Challenge: challenge, err: err}
+		}
+
+		return principal, nil
+	}
+}
+
+// ClientCertificate authenticates request with TLS client certificate
+// verified by server tls.Config, e.g. with tls.RequireAndVerifyClientCert.
+// Errors returned by verify are treated as rejected certificate.
+func ClientCertificate[P any](verify func(ctx context.Context, cert *x509.Certificate) (P, error)) Authenticator[P] {
+	return func(r *http.Request) (P, error) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+			var zero P
+			return zero, &UnauthorizedError{Challenge: "Certificate", err: errors.New("verified client certificate is missing")}
+		}
+
+		principal, err := verify(r.Context(), r.TLS.PeerCertificates[0])
+		if err != nil {
+			return principal, &UnauthorizedError{Challenge: "Certificate", err: err}
+		}
+
+		return principal, nil
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
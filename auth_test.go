@@ -0,0 +1,258 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testUser struct {
+	Subject string `json:"sub"`
+}
+
+func signTestJWT(alg, kid string, claims map[string]any, sign func([]byte) []byte) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+var _ = Describe("Authenticate", func() {
+	h := controller.Respond[string](func(r *http.Request) (string, error) {
+		user, ok := controller.Principal[testUser](r)
+		Expect(ok).To(BeTrue())
+
+		return user.Subject, nil
+	})
+
+	serve := func(action http.Handler, r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	request := func(authorization string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+
+		return r
+	}
+
+	verifyToken := func(_ context.Context, token string) (testUser, error) {
+		if token != "secret" {
+			return testUser{}, errors.New("unknown token")
+		}
+
+		return testUser{Subject: "alice"}, nil
+	}
+
+	It("authenticates bearer token", func() {
+		action := h.With(controller.Authenticate(controller.BearerToken(verifyToken)))
+
+		w := serve(action, request("Bearer secret"))
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("\"alice\"\n"))
+
+		w = serve(action, request(""))
+
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+
+		w = serve(action, request("Bearer other"))
+
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).To(Equal(`Bearer error="invalid_token"`))
+		Expect(w.Body.String()).To(Equal("\"unauthorized: unknown token\"\n"))
+	})
+
+	It("authenticates basic credentials", func() {
+		action := h.With(controller.Authenticate(controller.BasicAuth("api",
+			func(_ context.Context, user, password string) (testUser, error) {
+				if password != "pass" {
+					return testUser{}, errors.New("wrong password")
+				}
+
+				return testUser{Subject: user}, nil
+			},
+		)))
+
+		r := request("")
+		r.SetBasicAuth("bob", "pass")
+
+		Expect(serve(action, r).Body.String()).To(Equal("\"bob\"\n"))
+
+		r = request("")
+		r.SetBasicAuth("bob", "wrong")
+		w := serve(action, r)
+
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).To(Equal(`Basic realm="api", charset="UTF-8"`))
+	})
+
+	It("authenticates API key", func() {
+		action := h.With(controller.Authenticate(controller.APIKey("X-API-Key", verifyToken)))
+
+		r := request("")
+		r.Header.Set("X-API-Key", "secret")
+
+		Expect(serve(action, r).Body.String()).To(Equal("\"alice\"\n"))
+		Expect(serve(action, request("")).Header().Get("WWW-Authenticate")).To(Equal(`APIKey header="X-API-Key"`))
+	})
+
+	It("authenticates client certificate", func() {
+		action := h.With(controller.Authenticate(controller.ClientCertificate(
+			func(_ context.Context, cert *x509.Certificate) (testUser, error) {
+				return testUser{Subject: cert.Subject.CommonName}, nil
+			},
+		)))
+
+		Expect(serve(action, request("")).Code).To(Equal(http.StatusUnauthorized))
+
+		cert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+		cert.Subject.CommonName = "service"
+
+		r := request("")
+		r.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+
+		Expect(serve(action, r).Body.String()).To(Equal("\"service\"\n"))
+	})
+
+	Describe("JWT", func() {
+		var (
+			secret = []byte("hmac-secret")
+			edPub  ed25519.PublicKey
+			edKey  ed25519.PrivateKey
+			rsaKey *rsa.PrivateKey
+			keys   *controller.JWKS
+		)
+
+		signHS256 := func(claims map[string]any) string {
+			return signTestJWT("HS256", "", claims, func(b []byte) []byte {
+				mac := hmac.New(sha256.New, secret)
+				mac.Write(b)
+
+				return mac.Sum(nil)
+			})
+		}
+
+		BeforeEach(func() {
+			var err error
+
+			edPub, edKey, err = ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+
+			rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+
+			jwks := fmt.Sprintf(`{"keys": [
+				{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": %q},
+				{"kty": "RSA", "kid": "rsa", "n": %q, "e": %q}
+			]}`,
+				base64.RawURLEncoding.EncodeToString(edPub),
+				base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			)
+
+			path := filepath.Join(GinkgoT().TempDir(), "jwks.json")
+			Expect(os.WriteFile(path, []byte(jwks), 0o600)).To(Succeed())
+
+			keys, err = controller.ReadJWKS(path)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		action := func() http.Handler {
+			return h.With(controller.Authenticate(controller.JWT[testUser](controller.JWTConfig{
+				Secret:   secret,
+				Keys:     keys,
+				Issuer:   "issuer",
+				Audience: "api",
+			})))
+		}
+
+		claims := func() map[string]any {
+			return map[string]any{
+				"sub": "alice",
+				"iss": "issuer",
+				"aud": []string{"api", "other"},
+				"exp": time.Now().Add(time.Minute).Unix(),
+			}
+		}
+
+		It("verifies HS256 token", func() {
+			w := serve(action(), request("Bearer "+signHS256(claims())))
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(Equal("\"alice\"\n"))
+		})
+
+		It("verifies EdDSA token from JWKS", func() {
+			token := signTestJWT("EdDSA", "ed", claims(), func(b []byte) []byte {
+				return ed25519.Sign(edKey, b)
+			})
+
+			Expect(serve(action(), request("Bearer "+token)).Code).To(Equal(http.StatusOK))
+		})
+
+		It("verifies RS256 token from JWKS", func() {
+			token := signTestJWT("RS256", "rsa", claims(), func(b []byte) []byte {
+				digest := sha256.Sum256(b)
+				signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+				Expect(err).NotTo(HaveOccurred())
+
+				return signature
+			})
+
+			Expect(serve(action(), request("Bearer "+token)).Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects invalid tokens", func() {
+			expired := claims()
+			expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+			wrongAudience := claims()
+			wrongAudience["aud"] = "other"
+
+			tampered := signHS256(claims())
+			tampered = tampered[:len(tampered)-2] + "AA"
+
+			none := signTestJWT("none", "", claims(), func([]byte) []byte { return nil })
+
+			for _, token := range []string{signHS256(expired), signHS256(wrongAudience), tampered, none} {
+				w := serve(action(), request("Bearer "+token))
+
+				Expect(w.Code).To(Equal(http.StatusUnauthorized))
+				Expect(w.Header().Get("WWW-Authenticate")).To(Equal(`Bearer error="invalid_token"`))
+			}
+		})
+	})
+})
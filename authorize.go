@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// If request is denied by Authorize policy - ForbiddenError is returned.
+type ForbiddenError struct {
+	Policy string
+}
+
+func (err *ForbiddenError) Error() string {
+	return fmt.Sprintf("forbidden by policy %s", err.Policy)
+}
+
+// AuthorizationDecision is the outcome of Authorize policy evaluation passed to OnAuthorize hooks.
+type AuthorizationDecision struct {
+	Policy    string
+	Allowed   bool
+	Principal any
+	// Input is decoded input model, nil if policy does not depend on it.
+	Input any
+	// Err is the error policy evaluation failed with.
+	Err error
+}
+
+// Policy decides whether request is allowed to call handler.
+type Policy struct {
+	name string
+	// input is set if policy depends on decoded input model
+	input bool
+	check func(r *http.Request, input any) (bool, error)
+}
+
+// NewPolicy returns Policy with name used in errors, decisions and route table.
+func NewPolicy(name string, check func(*http.Request) (bool, error)) Policy {
+	return Policy{name: name, check: func(r *http.Request, _ any) (bool, error) { return check(r) }}
+}
+
+func (p Policy) String() string {
+	return p.name
+}
+
+// Allow is Policy that checks principal set with Authenticate option.
+// Requests without principal of type P are denied.
+func Allow[P any](name string, check func(r *http.Request, principal P) bool) Policy {
+	return NewPolicy(name, func(r *http.Request) (bool, error) {
+		principal, ok := Principal[P](r)
+		return ok && check(r, principal), nil
+	})
+}
+
+// AllowInput is Policy that checks principal set with Authenticate option against decoded input model,
+// e.g. to allow owner of resource.
+// Requests without principal of type P or input of type In are denied.
+// Policy depending on input can not be combined with Cache, Coalesce and Idempotent options,
+// as they respond without decoding input.
+func AllowInput[P, In any](name string, check func(principal P, input In) bool) Policy {
+	return Policy{
+		name:  name,
+		input: true,
+		check: func(r *http.Request, input any) (bool, error) {
+			principal, ok := Principal[P](r)
+			in, isIn := input.(In)
+
+			return ok && isIn && check(principal, in), nil
+		},
+	}
+}
+
+// Role is Policy that allows principal which roles contain role.
+func Role[P any](role string, roles func(P) []string) Policy {
+	return Allow(fmt.Sprintf("role(%s)", role), func(_ *http.Request, principal P) bool {
+		return slices.Contains(roles(principal), role)
+	})
+}
+
+// Scope is Policy that allows principal which scopes contain scope.
+func Scope[P any](scope string, scopes func(P) []string) Policy {
+	return Allow(fmt.Sprintf("scope(%s)", scope), func(_ *http.Request, principal P) bool {
+		return slices.Contains(scopes(principal), scope)
+	})
+}
+
+// And is Policy that allows request if all policies allow it.
+func And(policies ...Policy) Policy {
+	return Policy{
+		name:  joinPolicies(policies, " and "),
+		input: slices.ContainsFunc(policies, func(p Policy) bool { return p.input }),
+		check: func(r *http.Request, input any) (bool, error) {
+			for _, p := range policies {
+				if allowed, err := p.check(r, input); err != nil || !allowed {
+					return false, err
+				}
+			}
+
+			return true, nil
+		},
+	}
+}
+
+// Or is Policy that allows request if any of policies allows it.
+func Or(policies ...Policy) Policy {
+	return Policy{
+		name:  joinPolicies(policies, " or "),
+		input: slices.ContainsFunc(policies, func(p Policy) bool { return p.input }),
+		check: func(r *http.Request, input any) (bool, error) {
+			for _, p := range policies {
+				if allowed, err := p.check(r, input); err != nil || allowed {
+					return allowed, err
+				}
+			}
+
+			return false, nil
+		},
+	}
+}
+
+// Not is Policy that allows request if policy denies it.
+func Not(policy Policy) Policy {
+	return Policy{
+		name:  "not " + groupPolicy(policy.name),
+		input: policy.input,
+		check: func(r *http.Request, input any) (bool, error) {
+			allowed, err := policy.check(r, input)
+			return !allowed && err == nil, err
+		},
+	}
+}
+
+func joinPolicies(policies []Policy, sep string) string {
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		names[i] = groupPolicy(p.name)
+	}
+
+	return strings.Join(names, sep)
+}
+
+// groupPolicy wraps name of composed policy in parentheses.
+func groupPolicy(name string) string {
+	if strings.Contains(name, " and ") || strings.Contains(name, " or ") {
+		return "(" + name + ")"
+	}
+
+	return name
+}
+
+// Authorize denies requests policy does not allow with *ForbiddenError (403 Forbidden unless matched otherwise).
+// Policy is evaluated right after request is authenticated,
+// policy depending on input model is evaluated after input is decoded and before handler is called.
+// Several Authorize options must all allow request.
+func Authorize(policy Policy) func(Options) {
+	return func(o Options) { o.Authorize(policy) }
+}
+
+// OnAuthorize is called with every decision of Authorize policy, e.g. to keep audit log.
+func OnAuthorize(hook func(*http.Request, AuthorizationDecision)) func(Options) {
+	return func(o Options) { o.OnAuthorize(hook) }
+}
+
+func (opts *options) authorizeHandler(next http.Handler) http.Handler {
+	if opts.policy == nil || opts.policy.input {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := opts.evaluatePolicy(r, nil); err != nil {
+			opts.writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize evaluates policy depending on input model once input is decoded.
+func (opts *options) authorize(r *http.Request, input any) error {
+	if opts.policy == nil || !opts.policy.input {
+		return nil
+	}
+
+	return opts.evaluatePolicy(r, input)
+}
+
+// authorized evaluates policy depending on input model for handler without one.
+func (opts *options) authorized(handle handleFunc) handleFunc {
+	if opts.policy == nil || !opts.policy.input {
+		return handle
+	}
+
+	return func(r *http.Request) (any, error) {
+		if err := opts.evaluatePolicy(r, nil); err != nil {
+			return nil, err
+		}
+
+		return handle(r)
+	}
+}
+
+func (opts *options) evaluatePolicy(r *http.Request, input any) error {
+	allowed, err := opts.policy.check(r, input)
+
+	decision := AuthorizationDecision{
+		Policy:    opts.policy.name,
+		Allowed:   allowed && err == nil,
+		Principal: r.Context().Value(principalKey{}),
+		Input:     input,
+		Err:       err,
+	}
+
+	for _, hook := range opts.onAuthorize {
+		hook(r, decision)
+	}
+
+	metrics().Add("controller_authorization_decisions_total", 1, "route", r.Pattern, "allowed", strconv.FormatBool(decision.Allowed))
+
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return &ForbiddenError{Policy: opts.policy.name}
+	}
+
+	return nil
+}
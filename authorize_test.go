@@ -0,0 +1,215 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testAccount struct {
+	Name  string
+	Roles []string
+}
+
+type testDocumentInput struct {
+	Owner string `path:"owner"`
+}
+
+var _ = Describe("Authorize", func() {
+	var (
+		router    *controller.Router
+		decisions []controller.AuthorizationDecision
+	)
+
+	accounts := map[string]testAccount{
+		"alice": {Name: "alice"},
+		"admin": {Name: "admin", Roles: []string{"admin"}},
+		"guest": {Name: "guest", Roles: []string{"guest"}},
+	}
+
+	roles := func(account testAccount) []string { return account.Roles }
+
+	owner := controller.AllowInput("owner", func(account testAccount, in testDocumentInput) bool {
+		return account.Name == in.Owner
+	})
+
+	BeforeEach(func() {
+		decisions = nil
+
+		router = controller.NewRouter(
+			controller.Authenticate(controller.BearerToken(func(_ context.Context, token string) (testAccount, error) {
+				return accounts[token], nil
+			})),
+			controller.OnAuthorize(func(r *http.Request, decision controller.AuthorizationDecision) {
+				decisions = append(decisions, decision)
+			}),
+		)
+
+		router.Register("GET /documents/{owner}", controller.Handle[testDocumentInput, string](
+			func(r *http.Request, in testDocumentInput) (string, error) {
+				return in.Owner, nil
+			},
+		), controller.Authorize(controller.Or(owner, controller.Role("admin", roles))))
+
+		router.Register("GET /reports", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "report", nil
+		}), controller.Authorize(controller.Not(controller.Role("guest", roles))))
+	})
+
+	serve := func(target, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, strings.NewReader(""))
+		r.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("allows owner of resource or admin", func() {
+		Expect(serve("/documents/alice", "alice").Code).To(Equal(http.StatusOK))
+		Expect(serve("/documents/alice", "admin").Code).To(Equal(http.StatusOK))
+
+		w := serve("/documents/admin", "alice")
+
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).To(Equal("\"forbidden by policy owner or role(admin)\"\n"))
+	})
+
+	It("negates policy", func() {
+		Expect(serve("/reports", "alice").Code).To(Equal(http.StatusOK))
+		Expect(serve("/reports", "guest").Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("records every decision", func() {
+		serve("/documents/alice", "alice")
+		serve("/documents/admin", "guest")
+
+		Expect(decisions).To(HaveLen(2))
+
+		Expect(decisions[0].Allowed).To(BeTrue())
+		Expect(decisions[0].Principal).To(Equal(accounts["alice"]))
+		Expect(decisions[0].Input).To(Equal(testDocumentInput{Owner: "alice"}))
+
+		Expect(decisions[1].Allowed).To(BeFalse())
+		Expect(decisions[1].Policy).To(Equal("owner or role(admin)"))
+	})
+
+	It("lists policies in route table", func() {
+		routes := router.Routes()
+
+		Expect(routes[0].Policy).To(Equal("owner or role(admin)"))
+		Expect(routes[1].Policy).To(Equal("not role(guest)"))
+	})
+
+	It("requires every Authorize option to allow request", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "ok", nil
+		}).With(
+			controller.Authorize(controller.NewPolicy("open", func(*http.Request) (bool, error) { return true, nil })),
+			controller.Authorize(controller.NewPolicy("closed", func(*http.Request) (bool, error) { return false, nil })),
+		)
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).To(Equal("\"forbidden by policy open and closed\"\n"))
+	})
+
+	Describe("with response reuse", func() {
+		admin := controller.Role("admin", roles)
+		authenticate := controller.Authenticate(controller.BearerToken(func(_ context.Context, token string) (testAccount, error) {
+			return accounts[token], nil
+		}))
+
+		request := func(method, token string) *http.Request {
+			r := httptest.NewRequest(method, "/secret", strings.NewReader(`{}`))
+			r.Header.Set("Authorization", "Bearer "+token)
+			r.Header.Set("Idempotency-Key", "key")
+
+			return r
+		}
+
+		serveRequest := func(action http.Handler, r *http.Request) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, r)
+
+			return w
+		}
+
+		secret := func(r *http.Request) (string, error) {
+			account, _ := controller.Principal[testAccount](r)
+			return "secret for " + account.Name, nil
+		}
+
+		It("does not serve cached response to denied principal", func() {
+			action := controller.Respond[string](secret).With(
+				authenticate,
+				controller.Authorize(admin),
+				controller.Cache(controller.NewMemoryCacheStore(1<<20), time.Minute),
+			)
+
+			Expect(serveRequest(action, request(http.MethodGet, "admin")).Code).To(Equal(http.StatusOK))
+			Expect(serveRequest(action, request(http.MethodGet, "alice")).Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("does not share coalesced response with denied principal", func() {
+			started, release := make(chan struct{}), make(chan struct{})
+			action := controller.Respond[string](func(r *http.Request) (string, error) {
+				close(started)
+				<-release
+
+				return secret(r)
+			}).With(
+				authenticate,
+				controller.Authorize(admin),
+				controller.Coalesce(controller.CoalesceByURL),
+			)
+
+			done := make(chan *httptest.ResponseRecorder, 1)
+			go func() { done <- serveRequest(action, request(http.MethodGet, "admin")) }()
+
+			<-started
+
+			Expect(serveRequest(action, request(http.MethodGet, "alice")).Code).To(Equal(http.StatusForbidden))
+
+			close(release)
+
+			Expect((<-done).Code).To(Equal(http.StatusOK))
+		})
+
+		It("does not replay idempotent response to denied principal", func() {
+			action := controller.Respond[string](secret).With(
+				authenticate,
+				controller.Authorize(admin),
+				controller.Idempotent(controller.NewMemoryIdempotencyStore(time.Minute)),
+			)
+
+			Expect(serveRequest(action, request(http.MethodPost, "admin")).Code).To(Equal(http.StatusOK))
+			Expect(serveRequest(action, request(http.MethodPost, "alice")).Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("refuses policy depending on input", func() {
+			build := func(option func(controller.Options)) func() {
+				return func() {
+					controller.Handle[testDocumentInput, string](func(r *http.Request, in testDocumentInput) (string, error) {
+						return in.Owner, nil
+					}).With(authenticate, controller.Authorize(controller.Or(owner, admin)), option)
+				}
+			}
+
+			Expect(build(controller.Cache(controller.NewMemoryCacheStore(1<<20), time.Minute))).To(Panic())
+			Expect(build(controller.Coalesce(controller.CoalesceByURL))).To(Panic())
+			Expect(build(controller.Idempotent(controller.NewMemoryIdempotencyStore(time.Minute)))).To(Panic())
+			Expect(build(controller.Timeout(time.Minute))).NotTo(Panic())
+		})
+	})
+})
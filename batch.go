@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// If batch request exceeds configured limits - BatchLimitError is returned.
+type BatchLimitError struct {
+	Reason string
+}
+
+func (err *BatchLimitError) Error() string {
+	return "batch limit exceeded: " + err.Reason
+}
+
+// BatchRequest is single sub-request of batch.
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse is response to single sub-request of batch.
+// Body that is not valid JSON is returned as JSON string.
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchConfig configures Batch handler.
+type BatchConfig struct {
+	// Handler serves sub-requests, usually it is router with all endpoints registered.
+	Handler http.Handler
+	// MaxItems is maximal number of sub-requests in batch.
+	// 20 is used if 0.
+	MaxItems int
+	// MaxBodySize is maximal size of batch request body in bytes.
+	// 1 MiB is used if 0.
+	MaxBodySize int64
+	// Concurrency is number of sub-requests served in parallel.
+	// Sub-requests are served sequentially if 0 or 1.
+	Concurrency int
+}
+
+type batchKey struct{}
+
+// Batch is handler that reads JSON array of BatchRequest
+// and serves every sub-request with config.Handler in-process.
+// Sub-requests inherit headers of batch request unless they set their own.
+func Batch(config BatchConfig) Respond[[]BatchResponse] {
+	if config.MaxItems <= 0 {
+		config.MaxItems = 20
+	}
+
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+
+	config.Concurrency = max(config.Concurrency, 1)
+
+	return func(r *http.Request) ([]BatchResponse, error) {
+		if r.Context().Value(batchKey{}) != nil {
+			return nil, &ReadRequestError{err: errors.New("nested batch requests are not allowed")}
+		}
+
+		r.Body = http.MaxBytesReader(nil, r.Body, config.MaxBodySize)
+
+		items, err := ReadJSON[[]BatchRequest](r)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, &BatchLimitError{Reason: fmt.Sprintf("body is larger than %d bytes", config.MaxBodySize)}
+			}
+
+			return nil, err
+		}
+
+		if len(*items) > config.MaxItems {
+			return nil, &BatchLimitError{Reason: fmt.Sprintf("more than %d items", config.MaxItems)}
+		}
+
+		requests := make([]*http.Request, len(*items))
+		for i, item := range *items {
+			if requests[i], err = newBatchSubRequest(r, item); err != nil {
+				return nil, &ReadRequestError{err: fmt.Errorf("item %d: %w", i, err)}
+			}
+		}
+
+		responses := make([]BatchResponse, len(requests))
+		semaphore := make(chan struct{}, config.Concurrency)
+
+		var wg sync.WaitGroup
+		for i, sub := range requests {
+			semaphore <- struct{}{}
+			wg.Add(1)
+
+			go func() {
+				defer func() { <-semaphore }()
+				defer wg.Done()
+
+				responses[i] = serveBatchSubRequest(config.Handler, sub)
+			}()
+		}
+
+		wg.Wait()
+
+		return responses, nil
+	}
+}
+
+var batchSkipHeaders = []string{"Content-Length", "Content-Type", "Content-Encoding", "Accept-Encoding"}
+
+func newBatchSubRequest(r *http.Request, item BatchRequest) (*http.Request, error) {
+	method := strings.ToUpper(item.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if !strings.HasPrefix(item.Path, "/") {
+		return nil, fmt.Errorf("path %q is not absolute", item.Path)
+	}
+
+	ctx := context.WithValue(r.Context(), batchKey{}, struct{}{})
+
+	sub, err := http.NewRequestWithContext(ctx, method, item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Header = r.Header.Clone()
+	for _, name := range batchSkipHeaders {
+		sub.Header.Del(name)
+	}
+
+	if len(item.Body) > 0 {
+		sub.Header.Set("Content-Type", "application/json")
+	}
+
+	for name, value := range item.Headers {
+		sub.Header.Set(name, value)
+	}
+
+	sub.Host = r.Host
+	sub.RemoteAddr = r.RemoteAddr
+	sub.TLS = r.TLS
+
+	return sub, nil
+}
+
+func serveBatchSubRequest(handler http.Handler, sub *http.Request) (response BatchResponse) {
+	buf := newResponseBuffer(make(http.Header))
+
+	defer func() {
+		if rp := recover(); rp != nil {
+			logger().Error("batch item failed: recovered from panic", "error", rp, "path", sub.URL.Path)
+			response = BatchResponse{Status: http.StatusInternalServerError}
+		}
+	}()
+
+	handler.ServeHTTP(buf, sub)
+
+	response.Status = buf.status()
+	if len(buf.Header()) > 0 {
+		response.Headers = make(map[string]string, len(buf.Header()))
+		for name, values := range buf.Header() {
+			response.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+
+	body := bytes.TrimSpace(buf.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		response.Body = body
+	default:
+		response.Body, _ = json.Marshal(string(body))
+	}
+
+	return response
+}
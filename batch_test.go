@@ -0,0 +1,89 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	var mux *http.ServeMux
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		mux.Handle("GET /users/{id}", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "user " + r.PathValue("id") + " " + r.Header.Get("Authorization"), nil
+		}))
+		mux.Handle("POST /greetings", controller.Respond[string](func(r *http.Request) (string, error) {
+			greet, err := controller.ReadJSON[string](r)
+			if err != nil {
+				return "", err
+			}
+
+			return "hello " + *greet, nil
+		}).With(controller.SuccessCode(http.StatusCreated)))
+	})
+
+	post := func(action http.Handler, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer token")
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("serves every sub-request", func() {
+		action := controller.Batch(controller.BatchConfig{Handler: mux, Concurrency: 2})
+		w := post(action, `[
+			{"method": "GET", "path": "/users/1"},
+			{"method": "POST", "path": "/greetings", "body": "world"},
+			{"method": "GET", "path": "/unknown"}
+		]`)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var responses []controller.BatchResponse
+
+		Expect(json.Unmarshal(w.Body.Bytes(), &responses)).To(Succeed())
+		Expect(responses).To(HaveLen(3))
+
+		Expect(responses[0].Status).To(Equal(http.StatusOK))
+		Expect(responses[0].Headers["Content-Type"]).To(Equal("application/json; charset=utf-8"))
+		Expect(string(responses[0].Body)).To(Equal(`"user 1 Bearer token"`))
+
+		Expect(responses[1].Status).To(Equal(http.StatusCreated))
+		Expect(string(responses[1].Body)).To(Equal(`"hello world"`))
+
+		Expect(responses[2].Status).To(Equal(http.StatusNotFound))
+		Expect(string(responses[2].Body)).To(Equal(`"404 page not found"`))
+	})
+
+	It("limits number of items", func() {
+		action := controller.Batch(controller.BatchConfig{Handler: mux, MaxItems: 1})
+		w := post(action, `[{"path": "/users/1"}, {"path": "/users/2"}]`)
+
+		Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+	})
+
+	It("limits payload size", func() {
+		action := controller.Batch(controller.BatchConfig{Handler: mux, MaxBodySize: 16})
+		w := post(action, `[{"path": "/users/1"}]`)
+
+		Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+	})
+
+	It("rejects sub-request to other host", func() {
+		action := controller.Batch(controller.BatchConfig{Handler: mux})
+		w := post(action, `[{"path": "http://example.com/users/1"}]`)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+})
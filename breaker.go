@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of circuit breaker.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// If circuit breaker is open - CircuitOpenError is returned without calling handler.
+type CircuitOpenError struct {
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (err *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit is open, retry after %s", err.RetryAfter)
+}
+
+// BreakerConfig configures Breaker option.
+type BreakerConfig struct {
+	// Key selects circuit request belongs to.
+	// Single circuit is used for handler if nil.
+	Key func(*http.Request) string
+	// IsFailure reports whether handler outcome is a failure.
+	// status is HTTP Status Code err was matched to or success code if err is nil.
+	// Errors matched to 5xx codes are failures if nil.
+	IsFailure func(err error, status int) bool
+	// FailureThreshold is number of consecutive failures that opens circuit.
+	// 5 is used if 0.
+	FailureThreshold int
+	// OpenTimeout is the time circuit stays open before trial requests are allowed.
+	// 30 seconds are used if 0.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is number of successful trial requests that closes circuit.
+	// 1 is used if 0.
+	HalfOpenRequests int
+	// OnStateChange is called when circuit changes its state.
+	OnStateChange func(key string, from, to CircuitState)
+}
+
+// Breaker stops calling handler after consecutive failures
+// and fails fast with *CircuitOpenError (503 Service Unavailable unless matched otherwise)
+// until OpenTimeout passes and trial requests succeed.
+// State transitions are reported with Logger and Metrics.
+func Breaker(config BreakerConfig) func(Options) {
+	return func(o Options) { o.Breaker(config) }
+}
+
+func (opts *options) breakerHandle(handle handleFunc) handleFunc {
+	if opts.breaker == nil {
+		return handle
+	}
+
+	b := newBreaker(*opts.breaker)
+
+	return func(r *http.Request) (result any, err error) {
+		var key string
+		if b.Key != nil {
+			key = b.Key(r)
+		}
+
+		trial, err := b.allow(r, key)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if rp := recover(); rp != nil {
+				b.done(r, key, trial, true)
+
+				recovered, ok := rp.(*RecoveredError)
+				if !ok {
+					recovered = newRecoveredError(rp, debug.Stack())
+				}
+
+				panic(recovered)
+			}
+		}()
+
+		result, err = handle(r)
+		b.done(r, key, trial, b.isFailure(r, err, opts))
+
+		return result, err
+	}
+}
+
+type breaker struct {
+	BreakerConfig
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	// halfOpened counts transitions to half-open state of all circuits.
+	halfOpened uint64
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	trials    int
+	successes int
+	openedAt  time.Time
+	// trial identifies current half-open period, requests admitted in it carry the same value.
+	trial uint64
+}
+
+func newBreaker(config BreakerConfig) *breaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+
+	return &breaker{BreakerConfig: config, circuits: make(map[string]*circuit)}
+}
+
+// allow returns non-zero trial if request is admitted as trial request of half-open circuit.
+func (b *breaker) allow(r *http.Request, key string) (trial uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return 0, nil
+	}
+
+	if c.state == CircuitOpen {
+		if wait := b.OpenTimeout - time.Since(c.openedAt); wait > 0 {
+			return 0, &CircuitOpenError{Key: key, RetryAfter: wait}
+		}
+
+		b.transition(r, key, c, CircuitHalfOpen)
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.trials >= b.HalfOpenRequests {
+			return 0, &CircuitOpenError{Key: key}
+		}
+
+		c.trials++
+
+		return c.trial, nil
+	}
+
+	return 0, nil
+}
+
+// done records request outcome, outcomes of requests admitted before circuit became half-open
+// are ignored while it is half-open.
+func (b *breaker) done(r *http.Request, key string, trial uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		if !failed {
+			return
+		}
+
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	switch c.state {
+	case CircuitClosed:
+		if !failed {
+			// closed circuit without failures is not worth keeping
+			delete(b.circuits, key)
+			return
+		}
+
+		if c.failures++; c.failures >= b.FailureThreshold {
+			b.transition(r, key, c, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if trial != c.trial {
+			return
+		}
+
+		c.trials--
+
+		if failed {
+			b.transition(r, key, c, CircuitOpen)
+			return
+		}
+
+		if c.successes++; c.successes >= b.HalfOpenRequests {
+			b.transition(r, key, c, CircuitClosed)
+			delete(b.circuits, key)
+		}
+	}
+}
+
+// transition must be called with b.mu held.
+func (b *breaker) transition(r *http.Request, key string, c *circuit, to CircuitState) {
+	from := c.state
+
+	c.state = to
+	c.failures = 0
+	c.trials = 0
+	c.successes = 0
+
+	if to == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+
+	if to == CircuitHalfOpen {
+		b.halfOpened++
+		c.trial = b.halfOpened
+	}
+
+	logger().Error("circuit breaker state changed", "route", r.Pattern, "key", key, "from", from, "to", to)
+	metrics().Add("controller_circuit_transitions_total", 1, "route", r.Pattern, "key", key, "from", from.String(), "to", to.String())
+	metrics().Set("controller_circuit_state", float64(to), "route", r.Pattern, "key", key)
+
+	if b.OnStateChange != nil {
+		b.OnStateChange(key, from, to)
+	}
+}
+
+func (b *breaker) isFailure(r *http.Request, err error, opts *options) bool {
+	status := opts.successCode
+	if err != nil {
+		_, status = getErrorResponse(r, err, opts.errorHandlers)
+	}
+
+	if b.IsFailure != nil {
+		return b.IsFailure(err, status)
+	}
+
+	return err != nil && status >= http.StatusInternalServerError
+}
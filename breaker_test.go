@@ -0,0 +1,162 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Breaker", func() {
+	var (
+		calls       int
+		fail        bool
+		transitions []controller.CircuitState
+		action      http.Handler
+	)
+
+	BeforeEach(func() {
+		calls = 0
+		fail = true
+		transitions = nil
+
+		h := func(r *http.Request) (string, error) {
+			calls++
+			if fail {
+				return "", errors.New("dependency is down")
+			}
+
+			return "success", nil
+		}
+
+		action = controller.Respond[string](h).
+			With(controller.Breaker(controller.BreakerConfig{
+				FailureThreshold: 2,
+				OpenTimeout:      20 * time.Millisecond,
+				OnStateChange: func(_ string, _, to controller.CircuitState) {
+					transitions = append(transitions, to)
+				},
+			}))
+	})
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return w.Code
+	}
+
+	It("opens circuit after consecutive failures", func() {
+		Expect(get()).To(Equal(http.StatusInternalServerError))
+		Expect(get()).To(Equal(http.StatusInternalServerError))
+		Expect(get()).To(Equal(http.StatusServiceUnavailable))
+		Expect(calls).To(Equal(2))
+		Expect(transitions).To(Equal([]controller.CircuitState{controller.CircuitOpen}))
+	})
+
+	It("closes circuit after successful trial request", func() {
+		get()
+		get()
+		fail = false
+
+		time.Sleep(25 * time.Millisecond)
+
+		Expect(get()).To(Equal(http.StatusOK))
+		Expect(get()).To(Equal(http.StatusOK))
+		Expect(transitions).To(Equal([]controller.CircuitState{
+			controller.CircuitOpen,
+			controller.CircuitHalfOpen,
+			controller.CircuitClosed,
+		}))
+	})
+
+	It("opens circuit again after failed trial request", func() {
+		get()
+		get()
+
+		time.Sleep(25 * time.Millisecond)
+
+		Expect(get()).To(Equal(http.StatusInternalServerError))
+		Expect(get()).To(Equal(http.StatusServiceUnavailable))
+		Expect(calls).To(Equal(3))
+	})
+
+	It("ignores requests admitted before circuit became half-open", func() {
+		started := make(chan struct{}, 2)
+		release := map[string]chan struct{}{"slow": make(chan struct{}), "trial": make(chan struct{})}
+
+		action = controller.Respond[string](func(r *http.Request) (string, error) {
+			if wait, ok := release[r.URL.Query().Get("wait")]; ok {
+				started <- struct{}{}
+				<-wait
+
+				return "success", nil
+			}
+
+			return "", errors.New("dependency is down")
+		}).With(controller.Breaker(controller.BreakerConfig{
+			FailureThreshold: 1,
+			OpenTimeout:      20 * time.Millisecond,
+			OnStateChange: func(_ string, _, to controller.CircuitState) {
+				transitions = append(transitions, to)
+			},
+		}))
+
+		serve := func(target string) <-chan int {
+			done := make(chan int, 1)
+			go func() {
+				w := httptest.NewRecorder()
+				action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+				done <- w.Code
+			}()
+
+			return done
+		}
+
+		slow := serve("/?wait=slow")
+		<-started
+
+		Expect(get()).To(Equal(http.StatusInternalServerError))
+
+		time.Sleep(25 * time.Millisecond)
+
+		trial := serve("/?wait=trial")
+		<-started
+
+		close(release["slow"])
+
+		Expect(<-slow).To(Equal(http.StatusOK))
+		Expect(get()).To(Equal(http.StatusServiceUnavailable))
+		Expect(transitions).To(Equal([]controller.CircuitState{controller.CircuitOpen, controller.CircuitHalfOpen}))
+
+		close(release["trial"])
+
+		Expect(<-trial).To(Equal(http.StatusOK))
+		Expect(transitions).To(Equal([]controller.CircuitState{
+			controller.CircuitOpen,
+			controller.CircuitHalfOpen,
+			controller.CircuitClosed,
+		}))
+	})
+
+	It("does not count client errors as failures", func() {
+		h := func(r *http.Request) (string, error) {
+			calls++
+			return "", &testError{Detail: "bad input"}
+		}
+		action = controller.Respond[string](h).
+			With(
+				controller.ErrorWithCode[*testError](http.StatusBadRequest),
+				controller.Breaker(controller.BreakerConfig{FailureThreshold: 1}),
+			)
+
+		Expect(get()).To(Equal(http.StatusBadRequest))
+		Expect(get()).To(Equal(http.StatusBadRequest))
+		Expect(calls).To(Equal(2))
+	})
+})
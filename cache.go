@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is response stored by CacheStore.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Tags       []string
+	StoredAt   time.Time
+	// ExpiresAt is the moment response can not be served even as stale.
+	ExpiresAt time.Time
+}
+
+// CacheStore keeps encoded responses of cached handlers.
+type CacheStore interface {
+	// Get returns nil response if key was not found.
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+	Set(ctx context.Context, key string, response *CachedResponse) error
+	// Invalidate removes all responses stored with any of tags.
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// CacheConfig configures Cache option.
+type CacheConfig struct {
+	Store CacheStore
+	// TTL is the time response is served as fresh.
+	TTL time.Duration
+	// StaleWhileRevalidate is the time after TTL expired
+	// when stale response is served while being refreshed in background.
+	StaleWhileRevalidate time.Duration
+	// Vary lists request headers response varies on.
+	Vary []string
+	// Tags returns tags stored response can be invalidated with.
+	Tags func(*http.Request) []string
+	// Key returns client specific part of cache key, e.g. ID of authenticated Principal.
+	// Requests with Authorization header or authenticated Principal
+	// are neither served from cache nor stored unless Key is set.
+	Key func(*http.Request) string
+}
+
+// Cache stores successful GET and HEAD responses in store
+// and serves them for ttl without calling handler.
+// Responses to authenticated requests are not cached, see CacheConfig.Key.
+func Cache(store CacheStore, ttl time.Duration) func(Options) {
+	return CacheWith(CacheConfig{Store: store, TTL: ttl})
+}
+
+// CacheWith is Cache with full configuration.
+func CacheWith(config CacheConfig) func(Options) {
+	return func(o Options) { o.Cache(config) }
+}
+
+func (opts *options) cacheHandler(next http.Handler) http.Handler {
+	if opts.cache == nil || opts.cache.Store == nil {
+		return next
+	}
+
+	c := &responseCache{CacheConfig: *opts.cache, next: next}
+
+	return http.HandlerFunc(c.serveHTTP)
+}
+
+type responseCache struct {
+	CacheConfig
+
+	next       http.Handler
+	refreshing sync.Map
+}
+
+func (c *responseCache) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		c.next.ServeHTTP(w, r)
+		return
+	}
+
+	cacheControl := r.Header.Get("Cache-Control")
+	if hasCacheDirective(cacheControl, "no-store") {
+		c.next.ServeHTTP(w, r)
+		return
+	}
+
+	if c.Key == nil && isAuthenticated(r) {
+		c.next.ServeHTTP(w, r)
+		return
+	}
+
+	key := c.key(r)
+
+	if !hasCacheDirective(cacheControl, "no-cache") {
+		cached, err := c.Store.Get(r.Context(), key)
+		if err != nil {
+			logger().Error("failed to read cached response", "error", err)
+		}
+
+		if cached != nil {
+			age := time.Since(cached.StoredAt)
+			if age > c.TTL && age <= c.TTL+c.StaleWhileRevalidate {
+				c.refresh(r, key)
+			}
+
+			if age <= c.TTL+c.StaleWhileRevalidate {
+				c.writeCached(w, r, cached, age)
+				return
+			}
+		}
+	}
+
+	buf := c.fetch(r, key)
+	buf.flush(w)
+}
+
+// writeCached writes cached response or 304 Not Modified
+// if request conditional headers match its ETag or Last-Modified.
+func (c *responseCache) writeCached(w http.ResponseWriter, r *http.Request, cached *CachedResponse, age time.Duration) {
+	copyHeader(w.Header(), cached.Header)
+	w.Header().Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+
+	lastModified, _ := http.ParseTime(cached.Header.Get("Last-Modified"))
+	if isNotModified(r, cached.Header.Get("ETag"), lastModified) {
+		writeNotModified(w)
+		return
+	}
+
+	w.WriteHeader(cached.StatusCode)
+
+	if _, err := w.Write(cached.Body); err != nil {
+		logger().Error("failed to write response", "error", err)
+	}
+}
+
+// fetch calls handler and stores response if it is cacheable.
+func (c *responseCache) fetch(r *http.Request, key string) *responseBuffer {
+	buf := newResponseBuffer(make(http.Header))
+	c.next.ServeHTTP(buf, r)
+
+	header := buf.Header()
+	if header.Get("Cache-Control") == "" {
+		cacheControl := "max-age=" + strconv.FormatInt(int64(c.TTL/time.Second), 10)
+		if c.StaleWhileRevalidate > 0 {
+			cacheControl += ", stale-while-revalidate=" + strconv.FormatInt(int64(c.StaleWhileRevalidate/time.Second), 10)
+		}
+
+		header.Set("Cache-Control", cacheControl)
+	}
+
+	if len(c.Vary) > 0 {
+		header.Add("Vary", strings.Join(c.Vary, ", "))
+	}
+
+	if !isCacheable(buf) {
+		return buf
+	}
+
+	now := time.Now()
+	response := &CachedResponse{
+		StatusCode: buf.status(),
+		Header:     header.Clone(),
+		Body:       bytes.Clone(buf.body.Bytes()),
+		StoredAt:   now,
+		ExpiresAt:  now.Add(c.TTL + c.StaleWhileRevalidate),
+	}
+
+	if c.Tags != nil {
+		response.Tags = c.Tags(r)
+	}
+
+	if err := c.Store.Set(context.WithoutCancel(r.Context()), key, response); err != nil {
+		logger().Error("failed to store cached response", "error", err)
+	}
+
+	return buf
+}
+
+// refresh fetches fresh response in background unless it is already being refreshed.
+func (c *responseCache) refresh(r *http.Request, key string) {
+	if _, loaded := c.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	r = r.Clone(context.WithoutCancel(r.Context()))
+
+	go func() {
+		defer c.refreshing.Delete(key)
+		defer func() {
+			if rp := recover(); rp != nil {
+				logger().Error("failed to refresh cached response", "error", rp)
+			}
+		}()
+
+		c.fetch(r, key)
+	}()
+}
+
+func (c *responseCache) key(r *http.Request) string {
+	var key strings.Builder
+
+	key.WriteString(r.Method)
+	key.WriteByte(' ')
+	key.WriteString(r.Host)
+	key.WriteString(r.URL.RequestURI())
+
+	for _, name := range c.Vary {
+		key.WriteByte('\n')
+		key.WriteString(http.CanonicalHeaderKey(name))
+		key.WriteByte(':')
+		key.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+
+	if version := APIVersion(r.Context()); version != "" {
+		key.WriteString("\nAPI-Version:")
+		key.WriteString(version)
+	}
+
+	if c.Key != nil {
+		key.WriteString("\nKey:")
+		key.WriteString(c.Key(r))
+	}
+
+	return key.String()
+}
+
+// isAuthenticated reports whether response to r may be specific to client.
+func isAuthenticated(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Context().Value(principalKey{}) != nil
+}
+
+func isCacheable(buf *responseBuffer) bool {
+	code := buf.status()
+	if code < http.StatusOK || code >= http.StatusMultipleChoices || code == http.StatusPartialContent {
+		return false
+	}
+
+	if buf.Header().Get("Set-Cookie") != "" {
+		return false
+	}
+
+	cacheControl := buf.Header().Get("Cache-Control")
+
+	return !hasCacheDirective(cacheControl, "no-store") &&
+		!hasCacheDirective(cacheControl, "no-cache") &&
+		!hasCacheDirective(cacheControl, "private")
+}
+
+func hasCacheDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+
+	return false
+}
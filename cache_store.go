@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCacheStore is in-memory LRU CacheStore
+// that evicts least recently used responses once maxBytes is exceeded.
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	lru      *list.List
+	items    map[string]*list.Element
+	tags     map[string]map[string]struct{}
+}
+
+type cacheItem struct {
+	key      string
+	response *CachedResponse
+	size     int64
+}
+
+func NewMemoryCacheStore(maxBytes int64) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+func (s *MemoryCacheStore) Get(_ context.Context, key string) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	item := el.Value.(*cacheItem)
+	if !item.response.ExpiresAt.IsZero() && time.Now().After(item.response.ExpiresAt) {
+		s.remove(el)
+		return nil, nil
+	}
+
+	s.lru.MoveToFront(el)
+
+	return item.response, nil
+}
+
+func (s *MemoryCacheStore) Set(_ context.Context, key string, response *CachedResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+
+	item := &cacheItem{key: key, response: response, size: cacheItemSize(key, response)}
+	if item.size > s.maxBytes {
+		return nil
+	}
+
+	s.items[key] = s.lru.PushFront(item)
+	s.bytes += item.size
+
+	for _, tag := range response.Tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+
+		keys[key] = struct{}{}
+	}
+
+	for s.bytes > s.maxBytes {
+		s.remove(s.lru.Back())
+	}
+
+	return nil
+}
+
+func (s *MemoryCacheStore) Invalidate(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if el, ok := s.items[key]; ok {
+				s.remove(el)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Size returns number of bytes used by stored responses.
+func (s *MemoryCacheStore) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bytes
+}
+
+// remove must be called with s.mu held.
+func (s *MemoryCacheStore) remove(el *list.Element) {
+	item := el.Value.(*cacheItem)
+
+	s.lru.Remove(el)
+	delete(s.items, item.key)
+	s.bytes -= item.size
+
+	for _, tag := range item.response.Tags {
+		keys := s.tags[tag]
+		delete(keys, item.key)
+
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+func cacheItemSize(key string, response *CachedResponse) int64 {
+	size := len(key) + len(response.Body)
+	for name, values := range response.Header {
+		size += len(name)
+		for _, v := range values {
+			size += len(v)
+		}
+	}
+
+	for _, tag := range response.Tags {
+		size += len(tag)
+	}
+
+	return int64(size)
+}
@@ -0,0 +1,189 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		calls atomic.Int32
+		store *controller.MemoryCacheStore
+	)
+
+	h := func(r *http.Request) (string, error) {
+		return fmt.Sprintf("%d %s", calls.Add(1), r.Header.Get("Accept-Language")), nil
+	}
+
+	BeforeEach(func() {
+		calls.Store(0)
+		store = controller.NewMemoryCacheStore(1 << 20)
+	})
+
+	get := func(action http.Handler, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/items?page=1", nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("serves cached response without calling handler", func() {
+		action := controller.Respond[string](h).With(controller.Cache(store, time.Minute))
+
+		first := get(action)
+		second := get(action)
+
+		Expect(calls.Load()).To(Equal(int32(1)))
+		Expect(first.Header().Get("Cache-Control")).To(Equal("max-age=60"))
+		Expect(second.Code).To(Equal(http.StatusOK))
+		Expect(second.Header().Get("Age")).To(Equal("0"))
+		Expect(second.Header().Get("Content-Type")).To(Equal("application/json; charset=utf-8"))
+		Expect(second.Body.String()).To(Equal(first.Body.String()))
+	})
+
+	It("varies on configured headers", func() {
+		action := controller.Respond[string](h).
+			With(controller.CacheWith(controller.CacheConfig{
+				Store: store,
+				TTL:   time.Minute,
+				Vary:  []string{"Accept-Language"},
+			}))
+
+		en := get(action, "Accept-Language", "en")
+		uk := get(action, "Accept-Language", "uk")
+
+		Expect(get(action, "Accept-Language", "en").Body.String()).To(Equal(en.Body.String()))
+		Expect(calls.Load()).To(Equal(int32(2)))
+		Expect(uk.Header().Get("Vary")).To(Equal("Accept-Language"))
+		Expect(uk.Body.String()).To(Equal("\"2 uk\"\n"))
+	})
+
+	Describe("authenticated requests", func() {
+		secret := func(r *http.Request) (string, error) {
+			user, _ := controller.Principal[testUser](r)
+			return fmt.Sprintf("%d secret of %s", calls.Add(1), user.Subject), nil
+		}
+
+		authenticate := controller.Authenticate(controller.BearerToken(
+			func(_ context.Context, token string) (testUser, error) {
+				return testUser{Subject: token}, nil
+			},
+		))
+
+		It("are not shared between principals", func() {
+			action := controller.Respond[string](secret).With(authenticate, controller.Cache(store, time.Minute))
+
+			Expect(get(action, "Authorization", "Bearer alice").Body.String()).To(Equal("\"1 secret of alice\"\n"))
+			Expect(get(action, "Authorization", "Bearer bob").Body.String()).To(Equal("\"2 secret of bob\"\n"))
+			Expect(get(action, "Authorization", "Bearer alice").Body.String()).To(Equal("\"3 secret of alice\"\n"))
+		})
+
+		It("are cached per configured key", func() {
+			action := controller.Respond[string](secret).With(
+				authenticate,
+				controller.CacheWith(controller.CacheConfig{
+					Store: store,
+					TTL:   time.Minute,
+					Key: func(r *http.Request) string {
+						user, _ := controller.Principal[testUser](r)
+						return user.Subject
+					},
+				}),
+			)
+
+			Expect(get(action, "Authorization", "Bearer alice").Body.String()).To(Equal("\"1 secret of alice\"\n"))
+			Expect(get(action, "Authorization", "Bearer bob").Body.String()).To(Equal("\"2 secret of bob\"\n"))
+			Expect(get(action, "Authorization", "Bearer alice").Body.String()).To(Equal("\"1 secret of alice\"\n"))
+		})
+	})
+
+	It("bypasses cache on no-cache request", func() {
+		action := controller.Respond[string](h).With(controller.Cache(store, time.Minute))
+
+		get(action)
+		get(action, "Cache-Control", "no-cache")
+
+		Expect(calls.Load()).To(Equal(int32(2)))
+		Expect(get(action).Body.String()).To(Equal("\"2 \"\n"))
+	})
+
+	It("does not store errors", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			calls.Add(1)
+			return "", fmt.Errorf("oops")
+		}).With(controller.Cache(store, time.Minute))
+
+		get(action)
+		get(action)
+
+		Expect(calls.Load()).To(Equal(int32(2)))
+	})
+
+	It("invalidates responses by tag", func() {
+		action := controller.Respond[string](h).
+			With(controller.CacheWith(controller.CacheConfig{
+				Store: store,
+				TTL:   time.Minute,
+				Tags:  func(*http.Request) []string { return []string{"items"} },
+			}))
+
+		get(action)
+		Expect(store.Invalidate(context.Background(), "items")).To(Succeed())
+		get(action)
+
+		Expect(calls.Load()).To(Equal(int32(2)))
+		Expect(store.Size()).To(BeNumerically(">", 0))
+	})
+
+	It("refreshes stale response in background", func() {
+		action := controller.Respond[string](h).
+			With(controller.CacheWith(controller.CacheConfig{
+				Store:                store,
+				TTL:                  time.Millisecond,
+				StaleWhileRevalidate: time.Minute,
+			}))
+
+		get(action)
+		time.Sleep(5 * time.Millisecond)
+
+		Expect(get(action).Body.String()).To(Equal("\"1 \"\n"))
+		Eventually(calls.Load).Should(Equal(int32(2)))
+		Eventually(func() string { return get(action).Body.String() }).Should(Equal("\"2 \"\n"))
+	})
+
+	It("evicts least recently used responses over byte budget", func() {
+		store := controller.NewMemoryCacheStore(300)
+		response := func(body string) *controller.CachedResponse {
+			return &controller.CachedResponse{StatusCode: http.StatusOK, Body: []byte(body)}
+		}
+		ctx := context.Background()
+
+		Expect(store.Set(ctx, "a", response(string(make([]byte, 100))))).To(Succeed())
+		Expect(store.Set(ctx, "b", response(string(make([]byte, 100))))).To(Succeed())
+		_, _ = store.Get(ctx, "a")
+		Expect(store.Set(ctx, "c", response(string(make([]byte, 100))))).To(Succeed())
+
+		a, _ := store.Get(ctx, "a")
+		b, _ := store.Get(ctx, "b")
+		c, _ := store.Get(ctx, "c")
+
+		Expect(a).NotTo(BeNil())
+		Expect(b).To(BeNil())
+		Expect(c).NotTo(BeNil())
+		Expect(store.Size()).To(BeNumerically("<=", 300))
+	})
+})
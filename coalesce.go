@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+// Coalesce collapses concurrent GET and HEAD requests with the same key into single handler call.
+// Result or error is shared with every waiting request, panic is propagated as *RecoveredError.
+// Every request keeps its own cancellation,
+// shared call is cancelled only when all requests waiting for it are gone.
+// Requests with empty key are not coalesced.
+func Coalesce(key func(*http.Request) string) func(Options) {
+	return func(o Options) { o.Coalesce(key) }
+}
+
+// CoalesceByURL is Coalesce key function that uses request method and URL.
+func CoalesceByURL(r *http.Request) string {
+	return r.Method + " " + r.URL.String()
+}
+
+func (opts *options) coalesce(handle handleFunc) handleFunc {
+	key := opts.coalesceKey
+	if key == nil {
+		return handle
+	}
+
+	group := &flightGroup{flights: make(map[string]*flight)}
+
+	return func(r *http.Request) (any, error) {
+		if !isSafeMethod(r.Method) {
+			return handle(r)
+		}
+
+		k := key(r)
+		if k == "" {
+			return handle(r)
+		}
+
+		return group.do(r, k, handle)
+	}
+}
+
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	result any
+	err    error
+	panic  *RecoveredError
+}
+
+func (g *flightGroup) do(r *http.Request, key string, handle handleFunc) (any, error) {
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+
+		go g.run(r.WithContext(ctx), key, f, handle)
+	}
+
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.panic != nil {
+			panic(f.panic)
+		}
+
+		return f.result, f.err
+	case <-r.Context().Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+
+		return nil, r.Context().Err()
+	}
+}
+
+func (g *flightGroup) run(r *http.Request, key string, f *flight, handle handleFunc) {
+	defer func() {
+		if rp := recover(); rp != nil {
+			f.panic = newRecoveredError(rp, debug.Stack())
+		}
+
+		f.cancel()
+
+		g.mu.Lock()
+		g.forget(key, f)
+		g.mu.Unlock()
+
+		close(f.done)
+	}()
+
+	f.result, f.err = handle(r)
+}
+
+// forget must be called with g.mu held.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
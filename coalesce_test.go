@@ -0,0 +1,139 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Coalesce", func() {
+	const waiters = 10
+
+	var (
+		calls   atomic.Int32
+		arrived atomic.Int32
+		release chan struct{}
+	)
+
+	BeforeEach(func() {
+		calls.Store(0)
+		arrived.Store(0)
+		release = make(chan struct{})
+	})
+
+	arrive := controller.BeforeHandle(func(*http.Request) error {
+		arrived.Add(1)
+		return nil
+	})
+
+	serveConcurrently := func(action http.Handler, beforeRelease func(), requests ...*http.Request) []*httptest.ResponseRecorder {
+		var wg sync.WaitGroup
+
+		recorders := make([]*httptest.ResponseRecorder, len(requests))
+		for i, r := range requests {
+			recorders[i] = httptest.NewRecorder()
+
+			wg.Add(1)
+			go func(w *httptest.ResponseRecorder, r *http.Request) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				action.ServeHTTP(w, r)
+			}(recorders[i], r)
+		}
+
+		Eventually(arrived.Load).Should(Equal(int32(len(requests))))
+		time.Sleep(20 * time.Millisecond)
+		beforeRelease()
+		close(release)
+		wg.Wait()
+
+		return recorders
+	}
+
+	It("shares single handler call between identical requests", func() {
+		h := func(r *http.Request) (string, error) {
+			calls.Add(1)
+			<-release
+
+			return "success", nil
+		}
+		action := controller.Respond[string](h).With(controller.Coalesce(controller.CoalesceByURL), arrive)
+
+		requests := make([]*http.Request, waiters)
+		for i := range requests {
+			requests[i] = httptest.NewRequest(http.MethodGet, "/report", nil)
+		}
+
+		for _, w := range serveConcurrently(action, func() {}, requests...) {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(Equal("\"success\"\n"))
+		}
+
+		Expect(calls.Load()).To(Equal(int32(1)))
+	})
+
+	It("propagates panic to every request", func() {
+		var panics atomic.Int32
+		h := func(r *http.Request) (string, error) {
+			<-release
+			panic("boom")
+		}
+		action := controller.Respond[string](h).
+			With(
+				controller.Coalesce(controller.CoalesceByURL),
+				controller.OnPanic(func(r *http.Request, err *controller.RecoveredError) {
+					if err.Panic == "boom" {
+						panics.Add(1)
+					}
+				}),
+				arrive,
+			)
+
+		requests := make([]*http.Request, waiters)
+		for i := range requests {
+			requests[i] = httptest.NewRequest(http.MethodGet, "/report", nil)
+		}
+
+		for _, w := range serveConcurrently(action, func() {}, requests...) {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		}
+
+		Expect(panics.Load()).To(Equal(int32(waiters)))
+	})
+
+	It("keeps cancellation of every request", func() {
+		h := func(r *http.Request) (string, error) {
+			calls.Add(1)
+			<-release
+
+			return "success", r.Context().Err()
+		}
+		action := controller.Respond[string](h).With(controller.Coalesce(controller.CoalesceByURL), arrive)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelled := httptest.NewRequest(http.MethodGet, "/report", nil).WithContext(ctx)
+
+		recorders := serveConcurrently(
+			action,
+			func() {
+				cancel()
+				time.Sleep(20 * time.Millisecond)
+			},
+			httptest.NewRequest(http.MethodGet, "/report", nil),
+			cancelled,
+		)
+
+		Expect(recorders[0].Code).To(Equal(http.StatusOK))
+		Expect(recorders[1].Code).To(Equal(controller.StatusClientClosedRequest))
+		Expect(calls.Load()).To(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,308 @@
+package controller
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressWriter is compressing writer that can be reused after Reset.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// RegisterEncoding makes content-coding available for Compress option.
+// Encodings registered earlier are preferred if client accepts several encodings equally.
+// gzip and deflate are registered by default.
+func RegisterEncoding(name string, newWriter func(io.Writer) CompressWriter) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, ok := encodings[name]; !ok {
+		encodingNames = append(encodingNames, name)
+	}
+
+	encodings[name] = &sync.Pool{New: func() any { return newWriter(io.Discard) }}
+}
+
+var (
+	encodingsMu   sync.RWMutex
+	encodings     = make(map[string]*sync.Pool)
+	encodingNames []string
+)
+
+func init() {
+	RegisterEncoding("gzip", func(w io.Writer) CompressWriter { return gzip.NewWriter(w) })
+	RegisterEncoding("deflate", func(w io.Writer) CompressWriter { return zlib.NewWriter(w) })
+}
+
+// CompressConfig configures Compress option.
+type CompressConfig struct {
+	// Encodings lists content-codings in order of preference.
+	// All registered encodings are used if empty.
+	Encodings []string
+	// MinSize is minimal size of response body to compress.
+	// 1024 bytes are used if 0.
+	MinSize int
+	// SkipContentTypes lists Content-Type prefixes that are never compressed.
+	// Already compressed media types are skipped if nil.
+	SkipContentTypes []string
+}
+
+var defaultSkipContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+}
+
+// Compress compresses response body with encoding negotiated via Accept-Encoding header.
+func Compress(encodings ...string) func(Options) {
+	return CompressWith(CompressConfig{Encodings: encodings})
+}
+
+// CompressWith is Compress with full configuration.
+func CompressWith(config CompressConfig) func(Options) {
+	return func(o Options) { o.Compress(config) }
+}
+
+func (opts *options) compressHandler(next http.Handler) http.Handler {
+	if opts.compress == nil {
+		return next
+	}
+
+	config := *opts.compress
+	if config.MinSize == 0 {
+		config.MinSize = 1024
+	}
+
+	if config.SkipContentTypes == nil {
+		config.SkipContentTypes = defaultSkipContentTypes
+	}
+
+	encodingsMu.RLock()
+	if len(config.Encodings) == 0 {
+		config.Encodings = append([]string(nil), encodingNames...)
+	}
+
+	pools := make(map[string]*sync.Pool, len(config.Encodings))
+	for _, name := range config.Encodings {
+		if pool, ok := encodings[strings.ToLower(name)]; ok {
+			pools[name] = pool
+		}
+	}
+	encodingsMu.RUnlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), config.Encodings)
+		pool, ok := pools[encoding]
+		if !ok || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, config: &config, encoding: encoding, pool: pool}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers response until MinSize is reached
+// and then decides whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+
+	config   *CompressConfig
+	encoding string
+	pool     *sync.Pool
+
+	code    int
+	buf     []byte
+	decided bool
+	encoder CompressWriter
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.code == 0 {
+		cw.code = code
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.WriteHeader(http.StatusOK)
+
+	switch {
+	case cw.encoder != nil:
+		return cw.encoder.Write(p)
+	case cw.decided:
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.config.MinSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (cw *compressWriter) Flush() {
+	if cw.code != 0 && !cw.decided {
+		_ = cw.decide(len(cw.buf) >= cw.config.MinSize)
+	}
+
+	if cw.encoder != nil {
+		_ = cw.encoder.Flush()
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) decide(large bool) error {
+	cw.decided = true
+
+	if large && cw.shouldCompress() {
+		h := cw.Header()
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+
+		// compressed representation is not byte-for-byte identical to the one strong ETag was computed for
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		cw.encoder = cw.pool.Get().(CompressWriter)
+		cw.encoder.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	buf := cw.buf
+	cw.buf = nil
+
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+func (cw *compressWriter) shouldCompress() bool {
+	if cw.code < http.StatusOK || cw.code == http.StatusNoContent || cw.code == http.StatusNotModified {
+		return false
+	}
+
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := strings.ToLower(h.Get("Content-Type"))
+	for _, skip := range cw.config.SkipContentTypes {
+		if strings.HasPrefix(contentType, skip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (cw *compressWriter) close() {
+	if cw.code == 0 {
+		return
+	}
+
+	if !cw.decided {
+		if err := cw.decide(false); err != nil {
+			logger().Error("failed to write response", "error", err)
+		}
+	}
+
+	if cw.encoder != nil {
+		if err := cw.encoder.Close(); err != nil {
+			logger().Error("failed to write compressed response", "error", err)
+		}
+
+		cw.encoder.Reset(io.Discard)
+		cw.pool.Put(cw.encoder)
+		cw.encoder = nil
+	}
+}
+
+// negotiateEncoding returns supported encoding with the highest quality in Accept-Encoding.
+// Ties are resolved in favour of supported encoding listed first.
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		if q := encodingQuality(acceptEncoding, encoding); q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+func encodingQuality(acceptEncoding, encoding string) float64 {
+	wildcard := 0.0
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(entry, ";")
+		name = strings.TrimSpace(name)
+
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			value, ok := strings.CutPrefix(strings.ToLower(params), "q=")
+			if !ok {
+				continue
+			}
+
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		switch {
+		case strings.EqualFold(name, encoding):
+			return q
+		case name == "*":
+			wildcard = q
+		}
+	}
+
+	return wildcard
+}
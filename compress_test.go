@@ -0,0 +1,121 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compress", func() {
+	long := strings.Repeat("Hello World ", 200)
+
+	respond := func(body string, opts ...func(controller.Options)) http.Handler {
+		return controller.Respond[string](func(r *http.Request) (string, error) {
+			return body, nil
+		}).With(opts...)
+	}
+
+	get := func(action http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("compresses response with negotiated encoding", func() {
+		w := get(respond(long, controller.Compress()), "deflate;q=0.5, gzip")
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Encoding")).To(Equal("gzip"))
+		Expect(w.Header().Get("Vary")).To(Equal("Accept-Encoding"))
+
+		zr, err := gzip.NewReader(w.Body)
+
+		Expect(err).NotTo(HaveOccurred())
+
+		var result string
+
+		Expect(json.NewDecoder(zr).Decode(&result)).To(Succeed())
+		Expect(result).To(Equal(long))
+	})
+
+	It("respects encoding preference", func() {
+		w := get(respond(long, controller.Compress()), "gzip;q=0.5, deflate;q=0.8")
+
+		Expect(w.Header().Get("Content-Encoding")).To(Equal("deflate"))
+	})
+
+	It("does not compress small response", func() {
+		w := get(respond("small", controller.Compress()), "gzip")
+
+		Expect(w.Header().Get("Content-Encoding")).To(BeEmpty())
+		Expect(w.Header().Get("Vary")).To(Equal("Accept-Encoding"))
+		Expect(w.Body.String()).To(Equal("\"small\"\n"))
+	})
+
+	It("does not compress if encoding is not acceptable", func() {
+		w := get(respond(long, controller.Compress()), "br, gzip;q=0")
+
+		Expect(w.Header().Get("Content-Encoding")).To(BeEmpty())
+		Expect(w.Body.Len()).To(BeNumerically(">", len(long)))
+	})
+
+	It("does not compress already compressed content", func() {
+		writer := controller.WriteResponseFn(func(r *http.Request, w http.ResponseWriter, data any, status int) {
+			w.Header().Set("Content-Type", "image/png")
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, data.(string))
+		})
+		w := get(respond(long, controller.Compress(), controller.ResponseWriter(writer)), "gzip")
+
+		Expect(w.Header().Get("Content-Encoding")).To(BeEmpty())
+		Expect(w.Body.String()).To(Equal(long))
+	})
+
+	It("reads gzip request body", func() {
+		var body bytes.Buffer
+
+		zw := gzip.NewWriter(&body)
+		_, _ = io.WriteString(zw, `"Hello World"`)
+		Expect(zw.Close()).To(Succeed())
+
+		r := httptest.NewRequest(http.MethodPost, "/", &body)
+		r.Header.Set("Content-Encoding", "gzip")
+
+		greet, err := controller.ReadJSON[string](r)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*greet).To(Equal("Hello World"))
+	})
+
+	It("limits decompressed request body", func() {
+		var body bytes.Buffer
+
+		zw := gzip.NewWriter(&body)
+		_, _ = io.WriteString(zw, `"`+strings.Repeat("a", 11<<20)+`"`)
+		Expect(zw.Close()).To(Succeed())
+
+		r := httptest.NewRequest(http.MethodPost, "/", &body)
+		r.Header.Set("Content-Encoding", "gzip")
+
+		_, err := controller.ReadJSON[string](r)
+
+		var readErr *controller.ReadRequestError
+
+		Expect(errors.As(err, &readErr)).To(BeTrue())
+		Expect(errors.Is(err, controller.ErrRequestBodyTooLarge)).To(BeTrue())
+	})
+})
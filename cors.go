@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures CORS option.
+type CORSConfig struct {
+	// AllowedOrigins are origins allowed to make cross-origin requests.
+	// Origin may contain single "*" wildcard, e.g. "https://*.example.com", "*" allows any origin.
+	// Any origin is allowed if both AllowedOrigins and AllowedOriginPatterns are empty.
+	AllowedOrigins []string
+	// AllowedOriginPatterns are regular expressions origin is matched against.
+	AllowedOriginPatterns []*regexp.Regexp
+	// AllowedMethods are methods allowed in preflight requests.
+	// GET, HEAD and POST are allowed if empty.
+	AllowedMethods []string
+	// AllowedHeaders are request headers allowed in preflight requests.
+	// Headers requested by preflight request are allowed if empty.
+	AllowedHeaders []string
+	// ExposedHeaders are response headers exposed to client.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies and authorization headers.
+	// It requires explicit AllowedOrigins or AllowedOriginPatterns without "*" origin.
+	AllowCredentials bool
+	// MaxAge is the time preflight response can be cached for.
+	MaxAge time.Duration
+}
+
+// CORS answers preflight OPTIONS requests and adds CORS headers to every response of allowed origin,
+// including error responses.
+// Router registers OPTIONS route for every route with CORS option,
+// otherwise handler has to be registered for OPTIONS method.
+func CORS(config CORSConfig) func(Options) {
+	return func(o Options) { o.CORS(config) }
+}
+
+func (opts *options) corsHandler(next http.Handler) http.Handler {
+	if opts.cors == nil {
+		return next
+	}
+
+	config := *opts.cors
+	if config.AllowCredentials && config.allowsAnyOrigin() {
+		panic("controller: CORS with AllowCredentials requires explicit AllowedOrigins or AllowedOriginPatterns")
+	}
+
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+
+		if r.Method == http.MethodOptions && origin != "" && requestMethod != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+
+			if config.allowsOrigin(origin) && config.allowsMethod(requestMethod) {
+				config.setOrigin(h, origin)
+				h.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+
+				if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					if len(config.AllowedHeaders) == 0 {
+						h.Set("Access-Control-Allow-Headers", requested)
+					} else {
+						h.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+					}
+				}
+
+				if config.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		if origin != "" && config.allowsOrigin(origin) {
+			config.setOrigin(h, origin)
+
+			if len(config.ExposedHeaders) > 0 {
+				h.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflightHandler answers OPTIONS requests of route registered for other method.
+func (opts *options) preflightHandler() http.Handler {
+	return opts.corsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func (config CORSConfig) allowsAnyOrigin() bool {
+	return len(config.AllowedOrigins) == 0 && len(config.AllowedOriginPatterns) == 0 ||
+		slices.Contains(config.AllowedOrigins, "*")
+}
+
+func (config CORSConfig) allowsOrigin(origin string) bool {
+	if len(config.AllowedOrigins) == 0 && len(config.AllowedOriginPatterns) == 0 {
+		return true
+	}
+
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+
+	for _, pattern := range config.AllowedOriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (config CORSConfig) allowsMethod(method string) bool {
+	return slices.ContainsFunc(config.AllowedMethods, func(allowed string) bool {
+		return strings.EqualFold(allowed, method)
+	})
+}
+
+func (config CORSConfig) setOrigin(h http.Header, origin string) {
+	if config.allowsAnyOrigin() {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+
+	if config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,126 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CORS", func() {
+	var router *controller.Router
+
+	BeforeEach(func() {
+		router = controller.NewRouter(controller.ErrorWithCode[*testError](http.StatusBadRequest))
+
+		api := router.Group("/api", controller.CORS(controller.CORSConfig{
+			AllowedOrigins:        []string{"https://*.example.com"},
+			AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+			AllowedMethods:        []string{http.MethodGet, http.MethodPut},
+			AllowedHeaders:        []string{"Content-Type", "Authorization"},
+			ExposedHeaders:        []string{"ETag"},
+			AllowCredentials:      true,
+			MaxAge:                time.Hour,
+		}))
+		api.Register("GET /users/{id}", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "user", nil
+		}))
+		api.Register("PUT /users/{id}", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "", &testError{Detail: "invalid user"}
+		}))
+	})
+
+	serve := func(method, origin string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/users/1", nil)
+		r.Header.Set("Origin", origin)
+		for name, values := range header {
+			r.Header[name] = values
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("answers preflight request", func() {
+		w := serve(http.MethodOptions, "https://app.example.com", http.Header{
+			"Access-Control-Request-Method":  {http.MethodPut},
+			"Access-Control-Request-Headers": {"content-type"},
+		})
+
+		Expect(w.Code).To(Equal(http.StatusNoContent))
+		Expect(w.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+		Expect(w.Header().Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+		Expect(w.Header().Get("Access-Control-Allow-Methods")).To(Equal("GET, PUT"))
+		Expect(w.Header().Get("Access-Control-Allow-Headers")).To(Equal("Content-Type, Authorization"))
+		Expect(w.Header().Get("Access-Control-Max-Age")).To(Equal("3600"))
+	})
+
+	It("does not allow preflight of unknown origin or method", func() {
+		w := serve(http.MethodOptions, "https://example.org", http.Header{
+			"Access-Control-Request-Method": {http.MethodGet},
+		})
+
+		Expect(w.Header().Get("Access-Control-Allow-Origin")).To(BeEmpty())
+
+		w = serve(http.MethodOptions, "http://localhost:3000", http.Header{
+			"Access-Control-Request-Method": {http.MethodDelete},
+		})
+
+		Expect(w.Header().Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	})
+
+	It("decorates actual response", func() {
+		w := serve(http.MethodGet, "http://localhost:3000", nil)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Access-Control-Allow-Origin")).To(Equal("http://localhost:3000"))
+		Expect(w.Header().Get("Access-Control-Expose-Headers")).To(Equal("ETag"))
+		Expect(w.Header().Values("Vary")).To(ContainElement("Origin"))
+	})
+
+	It("decorates error response", func() {
+		w := serve(http.MethodPut, "https://app.example.com", nil)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+		Expect(w.Body.String()).To(ContainSubstring("invalid user"))
+	})
+
+	It("allows any origin by default", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "ok", nil
+		}).With(controller.CORS(controller.CORSConfig{}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Origin", "https://example.org")
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		Expect(w.Header().Get("Access-Control-Allow-Origin")).To(Equal("*"))
+	})
+
+	It("refuses credentials with any origin", func() {
+		build := func(config controller.CORSConfig) func() {
+			return func() {
+				controller.Respond[string](func(r *http.Request) (string, error) {
+					return "ok", nil
+				}).With(controller.CORS(config))
+			}
+		}
+
+		Expect(build(controller.CORSConfig{AllowCredentials: true})).To(Panic())
+		Expect(build(controller.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})).To(Panic())
+		Expect(build(controller.CORSConfig{
+			AllowedOrigins:   []string{"https://example.org"},
+			AllowCredentials: true,
+		})).NotTo(Panic())
+	})
+})
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// If request fails CSRF protection - CSRFError is returned.
+type CSRFError struct {
+	Reason string
+}
+
+func (err *CSRFError) Error() string {
+	return "CSRF check failed: " + err.Reason
+}
+
+// CSRFConfig configures CSRF option.
+type CSRFConfig struct {
+	// Session returns session identifier of request.
+	// If set synchronizer token pattern is used and token is derived from session with Secret,
+	// otherwise double-submit cookie pattern is used and token is kept in cookie.
+	Session func(*http.Request) string
+	// Secret signs tokens, it is required with Session.
+	Secret []byte
+	// CookieName is name of token cookie.
+	// "csrf_token" is used if empty.
+	CookieName string
+	// Cookie is template of token cookie, Name and Value are ignored.
+	// Cookie with Path "/", Secure, SameSite Lax and 12 hours MaxAge is used if nil.
+	Cookie *http.Cookie
+	// HeaderName is request header token is read from.
+	// "X-CSRF-Token" is used if empty.
+	HeaderName string
+	// FormField is form field token is read from if header is not set.
+	// "csrf_token" is used if empty.
+	FormField string
+	// TrustedOrigins are origins other than request host allowed to make unsafe requests,
+	// e.g. "https://app.example.com".
+	TrustedOrigins []string
+}
+
+type csrfKey struct{}
+
+// CSRF protects unsafe requests from cross-site request forgery.
+// Requests coming from other sites according to Origin or Sec-Fetch-Site headers are rejected,
+// then token sent in header or form field is verified.
+// Failures result in *CSRFError (403 Forbidden unless matched otherwise).
+// Token is available to handlers with CSRFToken.
+func CSRF(config CSRFConfig) func(Options) {
+	return func(o Options) { o.CSRF(config) }
+}
+
+// CSRFToken returns CSRF token of request to be rendered in form or sent to client.
+// It is empty for safe request without session if CSRFConfig.Session is set.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey{}).(string)
+	return token
+}
+
+func (opts *options) csrfHandler(next http.Handler) http.Handler {
+	if opts.csrf == nil {
+		return next
+	}
+
+	config := *opts.csrf
+	if config.Session != nil && len(config.Secret) == 0 {
+		panic("controller: CSRF with Session requires Secret")
+	}
+
+	if config.CookieName == "" {
+		config.CookieName = "csrf_token"
+	}
+
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+
+	if config.FormField == "" {
+		config.FormField = "csrf_token"
+	}
+
+	if config.Cookie == nil {
+		config.Cookie = &http.Cookie{Path: "/", Secure: true, SameSite: http.SameSiteLaxMode, MaxAge: int((12 * time.Hour).Seconds())}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := config.token(w, r)
+		if err == nil && !isSafeMethod(r.Method) {
+			err = config.verify(r, token)
+		}
+
+		if err != nil {
+			opts.writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfKey{}, token)))
+	})
+}
+
+// token returns expected token of request, issuing new token cookie if there is no valid one.
+func (config CSRFConfig) token(w http.ResponseWriter, r *http.Request) (string, error) {
+	if config.Session != nil {
+		session := config.Session(r)
+		if session == "" {
+			if isSafeMethod(r.Method) {
+				return "", nil
+			}
+
+			return "", &CSRFError{Reason: "session is missing"}
+		}
+
+		return config.sign(session), nil
+	}
+
+	if cookie, err := r.Cookie(config.CookieName); err == nil && config.valid(cookie.Value) {
+		return cookie.Value, nil
+	}
+
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+
+	token := base64.RawURLEncoding.EncodeToString(b[:])
+	if len(config.Secret) > 0 {
+		token += "." + config.sign(token)
+	}
+
+	cookie := *config.Cookie
+	cookie.Name, cookie.Value = config.CookieName, token
+	http.SetCookie(w, &cookie)
+
+	return token, nil
+}
+
+func (config CSRFConfig) verify(r *http.Request, token string) error {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		if !config.trusted(r.Header.Get("Origin")) {
+			return &CSRFError{Reason: "cross-site request"}
+		}
+	}
+
+	if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(r, origin) && !config.trusted(origin) {
+		return &CSRFError{Reason: "origin " + origin + " is not allowed"}
+	}
+
+	submitted := r.Header.Get(config.HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(config.FormField)
+	}
+
+	if submitted == "" {
+		return &CSRFError{Reason: "token is missing"}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+		return &CSRFError{Reason: "token is not valid"}
+	}
+
+	return nil
+}
+
+func (config CSRFConfig) sign(value string) string {
+	mac := hmac.New(sha256.New, config.Secret)
+	mac.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// valid reports whether double-submit cookie token is well-formed and signed if Secret is set.
+func (config CSRFConfig) valid(token string) bool {
+	if len(config.Secret) == 0 {
+		return token != ""
+	}
+
+	value, signature, ok := strings.Cut(token, ".")
+
+	return ok && hmac.Equal([]byte(signature), []byte(config.sign(value)))
+}
+
+func (config CSRFConfig) trusted(origin string) bool {
+	return origin != "" && slices.ContainsFunc(config.TrustedOrigins, func(trusted string) bool {
+		return strings.EqualFold(trusted, origin)
+	})
+}
+
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,158 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CSRF", func() {
+	h := controller.Respond[string](func(r *http.Request) (string, error) {
+		return controller.CSRFToken(r), nil
+	})
+
+	serve := func(action http.Handler, r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	Describe("double-submit cookie", func() {
+		var (
+			action http.Handler
+			cookie *http.Cookie
+		)
+
+		BeforeEach(func() {
+			action = h.With(controller.CSRF(controller.CSRFConfig{
+				Secret:         []byte("secret"),
+				TrustedOrigins: []string{"https://app.example.com"},
+			}))
+
+			w := serve(action, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Result().Cookies()).To(HaveLen(1))
+
+			cookie = w.Result().Cookies()[0]
+
+			Expect(cookie.Name).To(Equal("csrf_token"))
+			Expect(w.Body.String()).To(Equal(`"` + cookie.Value + "\"\n"))
+		})
+
+		post := func(token string, header http.Header) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.AddCookie(cookie)
+			r.Header.Set("X-CSRF-Token", token)
+			for name, values := range header {
+				r.Header[name] = values
+			}
+
+			return serve(action, r)
+		}
+
+		It("accepts token matching cookie", func() {
+			w := post(cookie.Value, http.Header{"Sec-Fetch-Site": {"same-origin"}})
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Result().Cookies()).To(BeEmpty())
+		})
+
+		It("accepts token from form field", func() {
+			form := url.Values{"csrf_token": {cookie.Value}}
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.AddCookie(cookie)
+
+			Expect(serve(action, r).Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects missing or wrong token", func() {
+			Expect(post("", nil).Code).To(Equal(http.StatusForbidden))
+
+			w := post("forged", nil)
+
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+			Expect(w.Body.String()).To(Equal("\"CSRF check failed: token is not valid\"\n"))
+		})
+
+		It("rejects cross-site requests", func() {
+			w := post(cookie.Value, http.Header{"Sec-Fetch-Site": {"cross-site"}, "Origin": {"https://evil.example"}})
+
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+
+			w = post(cookie.Value, http.Header{"Origin": {"https://evil.example"}})
+
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+			Expect(w.Body.String()).To(ContainSubstring("origin https://evil.example is not allowed"))
+		})
+
+		It("accepts trusted origin", func() {
+			w := post(cookie.Value, http.Header{"Sec-Fetch-Site": {"same-site"}, "Origin": {"https://app.example.com"}})
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		It("replaces unsigned cookie", func() {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: "csrf_token", Value: "forged"})
+
+			w := serve(action, r)
+
+			Expect(w.Result().Cookies()).To(HaveLen(1))
+			Expect(w.Result().Cookies()[0].Value).NotTo(Equal("forged"))
+		})
+	})
+
+	Describe("synchronizer token", func() {
+		action := h.With(controller.CSRF(controller.CSRFConfig{
+			Secret:  []byte("secret"),
+			Session: func(r *http.Request) string { return r.Header.Get("Session") },
+		}))
+
+		It("derives token from session", func() {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Session", "s1")
+
+			w := serve(action, r)
+
+			Expect(w.Result().Cookies()).To(BeEmpty())
+
+			token := strings.Trim(strings.TrimSpace(w.Body.String()), `"`)
+
+			r = httptest.NewRequest(http.MethodDelete, "/", nil)
+			r.Header.Set("Session", "s1")
+			r.Header.Set("X-CSRF-Token", token)
+
+			Expect(serve(action, r).Code).To(Equal(http.StatusOK))
+
+			r.Header.Set("Session", "s2")
+
+			Expect(serve(action, r).Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("skips safe request without session", func() {
+			w := serve(action, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(Equal("\"\"\n"))
+
+			Expect(serve(action, httptest.NewRequest(http.MethodDelete, "/", nil)).Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("refuses session without secret", func() {
+			Expect(func() {
+				h.With(controller.CSRF(controller.CSRFConfig{
+					Session: func(r *http.Request) string { return r.Header.Get("Session") },
+				}))
+			}).To(Panic())
+		})
+	})
+})
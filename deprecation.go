@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// If deprecated endpoint is called after its sunset - SunsetError is returned.
+type SunsetError struct {
+	Sunset time.Time
+}
+
+func (err *SunsetError) Error() string {
+	return fmt.Sprintf("endpoint was removed on %s", err.Sunset.UTC().Format(time.DateOnly))
+}
+
+// DeprecationConfig configures Deprecated option.
+type DeprecationConfig struct {
+	// Since is the time endpoint was deprecated.
+	// Deprecation header is "true" if zero.
+	Since time.Time
+	// Sunset is the time endpoint stops being served.
+	// Sunset header is not set if zero.
+	Sunset time.Time
+	// Link is URL of deprecation notice.
+	Link string
+	// ClientKey identifies client calls are counted for.
+	// Calls are counted per route only if nil.
+	ClientKey func(*http.Request) string
+	// Gone makes endpoint respond with *SunsetError (410 Gone unless matched otherwise) after Sunset.
+	Gone bool
+}
+
+// Deprecated adds Deprecation, Sunset and Link headers to every response of handler
+// and counts its calls with Metrics.
+func Deprecated(sunset time.Time, link string) func(Options) {
+	return DeprecatedWith(DeprecationConfig{Sunset: sunset, Link: link})
+}
+
+// DeprecatedWith is Deprecated with full configuration.
+func DeprecatedWith(config DeprecationConfig) func(Options) {
+	return func(o Options) { o.Deprecation(config) }
+}
+
+func (opts *options) deprecationHandler(next http.Handler) http.Handler {
+	if opts.deprecation == nil {
+		return next
+	}
+
+	config := *opts.deprecation
+
+	deprecation := "true"
+	if !config.Since.IsZero() {
+		deprecation = "@" + strconv.FormatInt(config.Since.Unix(), 10)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Deprecation", deprecation)
+
+		if !config.Sunset.IsZero() {
+			h.Set("Sunset", config.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if config.Link != "" {
+			h.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", config.Link))
+		}
+
+		attrs := []any{"route", r.Pattern}
+		if config.ClientKey != nil {
+			attrs = append(attrs, "client", config.ClientKey(r))
+		}
+
+		metrics().Add("controller_deprecated_calls_total", 1, attrs...)
+
+		if config.Gone && !config.Sunset.IsZero() && !time.Now().Before(config.Sunset) {
+			opts.writeError(w, r, &SunsetError{Sunset: config.Sunset})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,85 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deprecated", func() {
+	var metrics *testMetrics
+
+	BeforeEach(func() {
+		metrics = newTestMetrics()
+		controller.SetMetrics(metrics)
+	})
+
+	AfterEach(func() {
+		controller.SetMetrics(nil)
+	})
+
+	h := controller.Respond[string](func(r *http.Request) (string, error) {
+		return "", &testError{Detail: "failed"}
+	})
+
+	serve := func(action http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return w
+	}
+
+	It("adds deprecation headers to every response", func() {
+		sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+		action := h.With(
+			controller.DeprecatedWith(controller.DeprecationConfig{
+				Since:  time.Unix(1700000000, 0),
+				Sunset: sunset,
+				Link:   "https://example.com/deprecation",
+			}),
+			controller.ErrorWithCode[*testError](http.StatusBadRequest),
+		)
+
+		w := serve(action)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Header().Get("Deprecation")).To(Equal("@1700000000"))
+		Expect(w.Header().Get("Sunset")).To(Equal("Tue, 01 Jan 2030 00:00:00 GMT"))
+		Expect(w.Header().Get("Link")).To(Equal(`<https://example.com/deprecation>; rel="deprecation"`))
+
+		serve(action)
+
+		Expect(metrics.Get("controller_deprecated_calls_total")).To(Equal(2.0))
+	})
+
+	It("responds with gone after sunset", func() {
+		action := h.With(controller.DeprecatedWith(controller.DeprecationConfig{
+			Sunset: time.Now().Add(-time.Hour),
+			Gone:   true,
+		}))
+
+		w := serve(action)
+
+		Expect(w.Code).To(Equal(http.StatusGone))
+		Expect(w.Header().Get("Deprecation")).To(Equal("true"))
+	})
+
+	It("is listed in route table", func() {
+		sunset := time.Now().Add(time.Hour)
+
+		router := controller.NewRouter()
+		router.Register("GET /old", h, controller.Deprecated(sunset, ""))
+		router.Register("GET /new", h)
+
+		routes := router.Routes()
+
+		Expect(routes[0].Deprecation).NotTo(BeNil())
+		Expect(routes[0].Deprecation.Sunset).To(Equal(sunset))
+		Expect(routes[1].Deprecation).To(BeNil())
+	})
+})
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync/atomic"
+	"time"
 )
 
+// StatusClientClosedRequest is returned if client closed request before response was written.
+const StatusClientClosedRequest = 499
+
 // If request payload reading failed - ReadRequestError is returned.
 type ReadRequestError struct {
 	err error
@@ -20,6 +26,29 @@ func (err *ReadRequestError) Unwrap() error {
 	return err.err
 }
 
+// If handler did not finish in time set with Timeout option - TimeoutError is returned.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("handler timed out after %s", err.Timeout)
+}
+
+func (err *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// If Router has route for request path but not for its method - MethodNotAllowedError is returned.
+type MethodNotAllowedError struct {
+	Method string
+	Allow  []string
+}
+
+func (err *MethodNotAllowedError) Error() string {
+	return fmt.Sprintf("method %s is not allowed, allowed methods: %s", err.Method, strings.Join(err.Allow, ", "))
+}
+
 type RecoveredError struct {
 	Panic any
 	Stack []byte
@@ -57,7 +86,7 @@ func (match MatchError) Match(_ *http.Request, err error) (any, int) {
 
 func SetDefaultErrorHandlers(handlers ...ErrorMatcher) {
 	if len(handlers) > 0 {
-		handlers = append(handlers, readRequestErrorHandle)
+		handlers = append(handlers, builtinErrorHandlers...)
 		defaultErrorHandlers.Store(&handlers)
 	}
 }
@@ -71,8 +100,193 @@ var readRequestErrorHandle = MatchError(func(err error) (any, int) {
 	return nil, 0
 })
 
+var timeoutErrorHandle = MatchError(func(err error) (any, int) {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.Error(), http.StatusServiceUnavailable
+	}
+
+	return nil, 0
+})
+
+// client is gone, so there is no one to read response body
+var contextErrorHandle = MatchError(func(err error) (any, int) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return nil, StatusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return err.Error(), http.StatusGatewayTimeout
+	default:
+		return nil, 0
+	}
+})
+
+var idempotencyErrorHandle = MatchError(func(err error) (any, int) {
+	var (
+		conflictErr *IdempotencyConflictError
+		mismatchErr *IdempotencyMismatchError
+	)
+
+	switch {
+	case errors.As(err, &conflictErr):
+		return conflictErr.Error(), http.StatusConflict
+	case errors.As(err, &mismatchErr):
+		return mismatchErr.Error(), http.StatusUnprocessableEntity
+	default:
+		return nil, 0
+	}
+})
+
+var preconditionErrorHandle = MatchError(func(err error) (any, int) {
+	var preconditionErr *PreconditionFailedError
+	if errors.As(err, &preconditionErr) {
+		return preconditionErr.Error(), http.StatusPreconditionFailed
+	}
+
+	return nil, 0
+})
+
+var overloadedErrorHandle = MatchError(func(err error) (any, int) {
+	var overloadedErr *OverloadedError
+	if errors.As(err, &overloadedErr) {
+		return overloadedErr.Error(), http.StatusServiceUnavailable
+	}
+
+	return nil, 0
+})
+
+var rateLimitErrorHandle = MatchError(func(err error) (any, int) {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr.Error(), http.StatusTooManyRequests
+	}
+
+	return nil, 0
+})
+
+var circuitOpenErrorHandle = MatchError(func(err error) (any, int) {
+	var circuitErr *CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		return circuitErr.Error(), http.StatusServiceUnavailable
+	}
+
+	return nil, 0
+})
+
+var jobErrorHandle = MatchError(func(err error) (any, int) {
+	var (
+		notFoundErr  *JobNotFoundError
+		cancelledErr *JobCancelledError
+		closedErr    *JobRunnerClosedError
+	)
+
+	switch {
+	case errors.As(err, &notFoundErr):
+		return notFoundErr.Error(), http.StatusNotFound
+	case errors.As(err, &cancelledErr):
+		return cancelledErr.Error(), http.StatusGone
+	case errors.As(err, &closedErr):
+		return closedErr.Error(), http.StatusServiceUnavailable
+	default:
+		return nil, 0
+	}
+})
+
+var batchLimitErrorHandle = MatchError(func(err error) (any, int) {
+	var limitErr *BatchLimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.Error(), http.StatusRequestEntityTooLarge
+	}
+
+	return nil, 0
+})
+
+var methodNotAllowedErrorHandle = MatchError(func(err error) (any, int) {
+	var methodErr *MethodNotAllowedError
+	if errors.As(err, &methodErr) {
+		return methodErr.Error(), http.StatusMethodNotAllowed
+	}
+
+	return nil, 0
+})
+
+var versionErrorHandle = MatchError(func(err error) (any, int) {
+	var versionErr *UnsupportedVersionError
+	if errors.As(err, &versionErr) {
+		return versionErr.Error(), http.StatusBadRequest
+	}
+
+	return nil, 0
+})
+
+var sunsetErrorHandle = MatchError(func(err error) (any, int) {
+	var sunsetErr *SunsetError
+	if errors.As(err, &sunsetErr) {
+		return sunsetErr.Error(), http.StatusGone
+	}
+
+	return nil, 0
+})
+
+var unauthorizedErrorHandle = MatchError(func(err error) (any, int) {
+	var unauthorizedErr *UnauthorizedError
+	if errors.As(err, &unauthorizedErr) {
+		return unauthorizedErr.Error(), http.StatusUnauthorized
+	}
+
+	return nil, 0
+})
+
+var forbiddenErrorHandle = MatchError(func(err error) (any, int) {
+	var forbiddenErr *ForbiddenError
+	if errors.As(err, &forbiddenErr) {
+		return forbiddenErr.Error(), http.StatusForbidden
+	}
+
+	return nil, 0
+})
+
+var csrfErrorHandle = MatchError(func(err error) (any, int) {
+	var csrfErr *CSRFError
+	if errors.As(err, &csrfErr) {
+		return csrfErr.Error(), http.StatusForbidden
+	}
+
+	return nil, 0
+})
+
+var signatureErrorHandle = MatchError(func(err error) (any, int) {
+	var signatureErr *SignatureError
+	if errors.As(err, &signatureErr) {
+		return signatureErr.Error(), http.StatusUnauthorized
+	}
+
+	return nil, 0
+})
+
+var builtinErrorHandlers = []ErrorMatcher{
+	readRequestErrorHandle,
+	timeoutErrorHandle,
+	contextErrorHandle,
+	idempotencyErrorHandle,
+	preconditionErrorHandle,
+	overloadedErrorHandle,
+	rateLimitErrorHandle,
+	circuitOpenErrorHandle,
+	jobErrorHandle,
+	batchLimitErrorHandle,
+	methodNotAllowedErrorHandle,
+	versionErrorHandle,
+	sunsetErrorHandle,
+	unauthorizedErrorHandle,
+	forbiddenErrorHandle,
+	csrfErrorHandle,
+	signatureErrorHandle,
+}
+
 func init() {
-	defaultErrorHandlers.Store(&[]ErrorMatcher{readRequestErrorHandle})
+	handlers := append([]ErrorMatcher(nil), builtinErrorHandlers...)
+	defaultErrorHandlers.Store(&handlers)
 }
 
 var defaultErrorHandlers atomic.Pointer[[]ErrorMatcher]
@@ -88,7 +302,7 @@ func getErrorResponse(r *http.Request, err error, handlers []ErrorMatcher) (any,
 	return err.Error(), http.StatusInternalServerError
 }
 
-func newRecoveredError(p any, stack []byte) error {
+func newRecoveredError(p any, stack []byte) *RecoveredError {
 	return &RecoveredError{
 		Panic: p,
 		Stack: stack,
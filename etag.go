@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Versioned result is given weak ETag built from its version
+// instead of strong ETag computed from encoded response body.
+type Versioned interface {
+	Version() string
+}
+
+// Modified result is given Last-Modified header
+// and is checked against If-Modified-Since and If-Unmodified-Since headers.
+type Modified interface {
+	LastModified() time.Time
+}
+
+// If request precondition did not hold - PreconditionFailedError is returned.
+type PreconditionFailedError struct {
+	Header string
+}
+
+func (err *PreconditionFailedError) Error() string {
+	return "precondition failed: " + err.Header
+}
+
+// ETag sets ETag header on successful responses
+// and answers GET and HEAD requests with 304 Not Modified
+// if If-None-Match or If-Modified-Since header matches the result.
+func ETag() func(Options) {
+	return func(o Options) { o.ETag(nil) }
+}
+
+// ETagPrecondition is ETag that also checks If-Match and If-Unmodified-Since headers
+// of unsafe requests against current resource state before handler is called.
+// current returns resource in the form handler would respond with it.
+// If-Match uses strong comparison, so weak ETag of Versioned resource never matches it
+// and If-Unmodified-Since has to be used for such resources instead.
+func ETagPrecondition(current func(*http.Request) (any, error)) func(Options) {
+	return func(o Options) { o.ETag(current) }
+}
+
+type etagConfig struct {
+	current func(*http.Request) (any, error)
+}
+
+func (opts *options) writeWithETag(r *http.Request, w http.ResponseWriter, result any, code int) {
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		opts.responseWriter.Write(r, w, result, code)
+		return
+	}
+
+	var lastModified time.Time
+	if modified, ok := result.(Modified); ok {
+		lastModified = modified.LastModified().UTC().Truncate(time.Second)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if versioned, ok := result.(Versioned); ok {
+		etag := weakETag(versioned)
+		w.Header().Set("ETag", etag)
+
+		if isNotModified(r, etag, lastModified) {
+			writeNotModified(w)
+			return
+		}
+
+		opts.responseWriter.Write(r, w, result, code)
+
+		return
+	}
+
+	buf := newResponseBuffer(w.Header().Clone())
+	opts.responseWriter.Write(r, buf, result, code)
+
+	etag := strongETag(buf.body.Bytes())
+	buf.Header().Set("ETag", etag)
+
+	if isNotModified(r, etag, lastModified) {
+		copyHeader(w.Header(), buf.Header())
+		writeNotModified(w)
+
+		return
+	}
+
+	buf.flush(w)
+}
+
+func (opts *options) checkPreconditions(r *http.Request) error {
+	if opts.etag == nil || opts.etag.current == nil || isSafeMethod(r.Method) {
+		return nil
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	ifUnmodifiedSince := r.Header.Get("If-Unmodified-Since")
+	if ifMatch == "" && ifUnmodifiedSince == "" {
+		return nil
+	}
+
+	current, err := opts.etag.current(r)
+	if err != nil {
+		return err
+	}
+
+	if ifMatch != "" {
+		if current == nil {
+			return &PreconditionFailedError{Header: "If-Match"}
+		}
+
+		if strings.TrimSpace(ifMatch) == "*" || matchETag(ifMatch, opts.currentETag(r, current), true) {
+			return nil
+		}
+
+		return &PreconditionFailedError{Header: "If-Match"}
+	}
+
+	modified, ok := current.(Modified)
+	if !ok {
+		return nil
+	}
+
+	since, err := http.ParseTime(ifUnmodifiedSince)
+	if err == nil && modified.LastModified().Truncate(time.Second).After(since) {
+		return &PreconditionFailedError{Header: "If-Unmodified-Since"}
+	}
+
+	return nil
+}
+
+// currentETag returns ETag current resource would be written with.
+func (opts *options) currentETag(r *http.Request, current any) string {
+	if versioned, ok := current.(Versioned); ok {
+		return weakETag(versioned)
+	}
+
+	buf := newResponseBuffer(make(http.Header))
+	opts.responseWriter.Write(r, buf, current, http.StatusOK)
+
+	return strongETag(buf.body.Bytes())
+}
+
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return strings.TrimSpace(ifNoneMatch) == "*" || matchETag(ifNoneMatch, etag, false)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+
+	return err == nil && !lastModified.After(since)
+}
+
+func writeNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// matchETag compares etag with every entity tag in list.
+// Strong comparison requires both tags to be strong, weak comparison ignores "W/" prefix.
+func matchETag(list, etag string, strong bool) bool {
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if strong && strings.HasPrefix(tag, "W/") {
+			continue
+		}
+
+		if strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func weakETag(versioned Versioned) string {
+	return `W/"` + strings.ReplaceAll(versioned.Version(), `"`, "") + `"`
+}
+
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
@@ -0,0 +1,166 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testDocument struct {
+	Title    string    `json:"title"`
+	Revision string    `json:"-"`
+	Updated  time.Time `json:"-"`
+}
+
+func (d testDocument) Version() string {
+	return d.Revision
+}
+
+func (d testDocument) LastModified() time.Time {
+	return d.Updated
+}
+
+var _ = Describe("ETag", func() {
+	updated := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	document := testDocument{Title: "draft", Revision: "7", Updated: updated}
+
+	serve := func(action http.Handler, method string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", strings.NewReader(`{}`))
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("sets strong ETag computed from body", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "success", nil
+		}).With(controller.ETag())
+
+		w := serve(action, http.MethodGet)
+		etag := w.Header().Get("ETag")
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(etag).To(HavePrefix(`"`))
+
+		w = serve(action, http.MethodGet, "If-None-Match", `"other", `+etag)
+
+		Expect(w.Code).To(Equal(http.StatusNotModified))
+		Expect(w.Header().Get("ETag")).To(Equal(etag))
+		Expect(w.Body.Bytes()).To(BeEmpty())
+	})
+
+	It("sets weak ETag for Versioned result", func() {
+		action := controller.Respond[testDocument](func(r *http.Request) (testDocument, error) {
+			return document, nil
+		}).With(controller.ETag())
+
+		w := serve(action, http.MethodGet)
+
+		Expect(w.Header().Get("ETag")).To(Equal(`W/"7"`))
+		Expect(w.Header().Get("Last-Modified")).To(Equal("Wed, 01 May 2024 10:00:00 GMT"))
+		Expect(serve(action, http.MethodGet, "If-None-Match", `W/"7"`).Code).To(Equal(http.StatusNotModified))
+		Expect(serve(action, http.MethodGet, "If-None-Match", `W/"6"`).Code).To(Equal(http.StatusOK))
+	})
+
+	It("answers cached response with 304 Not Modified", func() {
+		calls := 0
+		action := controller.Respond[testDocument](func(r *http.Request) (testDocument, error) {
+			calls++
+			return document, nil
+		}).With(controller.ETag(), controller.Cache(controller.NewMemoryCacheStore(1<<20), time.Minute))
+
+		Expect(serve(action, http.MethodGet).Code).To(Equal(http.StatusOK))
+
+		w := serve(action, http.MethodGet, "If-None-Match", `W/"7"`)
+
+		Expect(w.Code).To(Equal(http.StatusNotModified))
+		Expect(w.Header().Get("ETag")).To(Equal(`W/"7"`))
+		Expect(w.Body.Bytes()).To(BeEmpty())
+		Expect(
+			serve(action, http.MethodGet, "If-Modified-Since", updated.Format(http.TimeFormat)).Code,
+		).To(Equal(http.StatusNotModified))
+		Expect(serve(action, http.MethodGet, "If-None-Match", `W/"6"`).Code).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("checks If-Modified-Since", func() {
+		action := controller.Respond[testDocument](func(r *http.Request) (testDocument, error) {
+			return document, nil
+		}).With(controller.ETag())
+
+		Expect(
+			serve(action, http.MethodGet, "If-Modified-Since", updated.Format(http.TimeFormat)).Code,
+		).To(Equal(http.StatusNotModified))
+		Expect(
+			serve(action, http.MethodGet, "If-Modified-Since", updated.Add(-time.Hour).Format(http.TimeFormat)).Code,
+		).To(Equal(http.StatusOK))
+	})
+
+	It("checks If-Match before handler is called", func() {
+		called := false
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			called = true
+			return "final", nil
+		}).With(controller.ETagPrecondition(func(r *http.Request) (any, error) {
+			return "draft", nil
+		}))
+
+		etag := serve(controller.Respond[string](func(r *http.Request) (string, error) {
+			return "draft", nil
+		}).With(controller.ETag()), http.MethodGet).Header().Get("ETag")
+
+		w := serve(action, http.MethodPut, "If-Match", `"other"`)
+
+		Expect(w.Code).To(Equal(http.StatusPreconditionFailed))
+		Expect(called).To(BeFalse())
+
+		w = serve(action, http.MethodPut, "If-Match", "W/"+etag)
+
+		Expect(w.Code).To(Equal(http.StatusPreconditionFailed))
+		Expect(called).To(BeFalse())
+
+		w = serve(action, http.MethodPut, "If-Match", `"other", `+etag)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(called).To(BeTrue())
+	})
+
+	It("does not match weak ETag with If-Match", func() {
+		action := controller.Respond[testDocument](func(r *http.Request) (testDocument, error) {
+			return testDocument{Title: "final", Revision: "8"}, nil
+		}).With(controller.ETagPrecondition(func(r *http.Request) (any, error) {
+			return document, nil
+		}))
+
+		Expect(serve(action, http.MethodPut, "If-Match", `W/"7"`).Code).To(Equal(http.StatusPreconditionFailed))
+		Expect(serve(action, http.MethodPut, "If-Match", `"7"`).Code).To(Equal(http.StatusPreconditionFailed))
+
+		w := serve(action, http.MethodPut, "If-Match", "*")
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("ETag")).To(Equal(`W/"8"`))
+	})
+
+	It("checks If-Unmodified-Since before handler is called", func() {
+		action := controller.Respond[testDocument](func(r *http.Request) (testDocument, error) {
+			return document, nil
+		}).With(controller.ETagPrecondition(func(r *http.Request) (any, error) {
+			return document, nil
+		}))
+
+		w := serve(action, http.MethodDelete, "If-Unmodified-Since", updated.Add(-time.Hour).Format(http.TimeFormat))
+
+		Expect(w.Code).To(Equal(http.StatusPreconditionFailed))
+	})
+})
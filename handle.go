@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Handle is http.Handler that reads In from request and responds with Out.
+// In is decoded from JSON request body if it is not empty,
+// then struct fields tagged with `path:"name"` are set from path values of ServeMux pattern,
+// e.g. field tagged with `path:"id"` is set from {id} of "GET /users/{id}".
+// Malformed path values result in *ReadRequestError.
+type Handle[In, Out any] func(*http.Request, In) (Out, error)
+
+// With allows change default Handle behaviour with options.
+func (handle Handle[In, Out]) With(opts ...func(Options)) http.Handler {
+	options := newOptions()
+	for _, option := range opts {
+		option(options)
+	}
+
+	return handle.getHttpHandle(options)
+}
+
+func (handle Handle[In, Out]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle.getHttpHandle(newOptions()).ServeHTTP(w, r)
+}
+
+func (handle Handle[In, Out]) getHttpHandle(opts *options) http.Handler {
+	return opts.handler(handle.getHandleFunc(opts))
+}
+
+func (handle Handle[In, Out]) getHandleFunc(opts *options) handleFunc {
+	return func(r *http.Request) (any, error) {
+		in, err := bindRequest[In](r)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := opts.authorize(r, in); err != nil {
+			return nil, err
+		}
+
+		return handle(r, in)
+	}
+}
+
+var errUnsupportedPathValue = errors.New("unsupported field type")
+
+func bindRequest[In any](r *http.Request) (In, error) {
+	if span, ok := spanFromContext(r.Context()); ok {
+		defer func(start time.Time) { span.AddEvent("read", "duration", time.Since(start)) }(time.Now())
+	}
+
+	var in In
+
+	b, err := readBody(r)
+	if err != nil {
+		return in, &ReadRequestError{err: err}
+	}
+
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &in); err != nil {
+			return in, &ReadRequestError{err: err}
+		}
+	}
+
+	v := reflect.ValueOf(&in).Elem()
+	if v.Kind() != reflect.Struct {
+		return in, nil
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		name := field.Tag.Get("path")
+		if name == "" || !field.IsExported() {
+			continue
+		}
+
+		value := r.PathValue(name)
+		if value == "" {
+			continue
+		}
+
+		if err := setPathValue(v.Field(i), value); err != nil {
+			if errors.Is(err, errUnsupportedPathValue) {
+				return in, fmt.Errorf("path value %q: %w %s", name, err, field.Type)
+			}
+
+			return in, &ReadRequestError{err: fmt.Errorf("path value %q: %w", name, err)}
+		}
+	}
+
+	return in, nil
+}
+
+func setPathValue(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	default:
+		return errUnsupportedPathValue
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// handleFunc is type-erased Respond used to build handler pipeline.
+type handleFunc func(*http.Request) (any, error)
+
+// handler builds http.Handler around handle.
+// Stages are listed from the innermost to the outermost one.
+func (opts *options) handler(handle handleFunc) http.Handler {
+	if opts.policy != nil && opts.policy.input &&
+		(opts.cache != nil || opts.coalesceKey != nil || opts.idempotency != nil) {
+		panic("controller: Authorize policy depending on input can not be combined with Cache, Coalesce or Idempotent")
+	}
+
+	handle = opts.unitOfWorkHandle(handle)
+	handle = opts.breakerHandle(handle)
+	handle = opts.coalesce(handle)
+
+	var h http.Handler = opts.serve(handle)
+	h = opts.scopeHandler(h)
+	h = opts.timeoutHandler(h)
+	h = opts.idempotencyHandler(h)
+	h = opts.signatureHandler(h)
+	h = opts.limitHandler(h)
+	h = opts.cacheHandler(h)
+	h = opts.rateLimitHandler(h)
+	h = opts.compressHandler(h)
+	h = opts.csrfHandler(h)
+	h = opts.authorizeHandler(h)
+	h = opts.authenticateHandler(h)
+	h = opts.deprecationHandler(h)
+
+	for i := len(opts.middlewares) - 1; i >= 0; i-- {
+		h = opts.middlewares[i](h)
+	}
+
+	h = opts.corsHandler(h)
+	h = opts.secureHeadersHandler(h)
+	h = trace(h)
+
+	return h
+}
+
+func (opts *options) serve(handle handleFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rp := recover(); rp != nil {
+				// panic might be already recovered elsewhere and propagated to this request
+				err, ok := rp.(*RecoveredError)
+				if !ok {
+					err = newRecoveredError(rp, debug.Stack())
+				}
+
+				logger().Error("request failed: recovered from panic during request", "error", err, "stack", string(err.Stack))
+
+				if span, ok := spanFromContext(r.Context()); ok {
+					span.RecordPanic(err)
+				}
+
+				for _, hook := range opts.onPanic {
+					hook(r, err)
+				}
+
+				failScope(r, err)
+
+				opts.writeError(w, r, err)
+			}
+		}()
+
+		result, err := opts.call(handle, r)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger().Error("request failed", "error", err)
+			}
+
+			failScope(r, err)
+
+			opts.writeError(w, r, err)
+
+			return
+		}
+
+		opts.write(w, r, result, opts.successCode)
+	}
+}
+
+func (opts *options) call(handle handleFunc, r *http.Request) (result any, err error) {
+	for _, hook := range opts.beforeHandle {
+		if err = hook(r); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = opts.checkPreconditions(r)
+	}
+
+	if err == nil {
+		result, err = handle(r)
+	}
+
+	for _, hook := range opts.afterHandle {
+		hook(r, unwrapResult(result), err)
+	}
+
+	return result, err
+}
+
+// withHeader is result that sets response headers before it is written.
+type withHeader struct {
+	value  any
+	header http.Header
+}
+
+func unwrapResult(result any) any {
+	if wh, ok := result.(withHeader); ok {
+		return wh.value
+	}
+
+	return result
+}
+
+func (opts *options) write(w http.ResponseWriter, r *http.Request, result any, code int) {
+	if wh, ok := result.(withHeader); ok {
+		copyHeader(w.Header(), wh.header)
+		result = wh.value
+	}
+
+	write := opts.responseWriter.Write
+	if opts.etag != nil {
+		write = opts.writeWithETag
+	}
+
+	span, ok := spanFromContext(r.Context())
+	if !ok {
+		write(r, w, result, code)
+		return
+	}
+
+	start := time.Now()
+	write(r, w, result, code)
+
+	span.AddEvent("write", "duration", time.Since(start))
+}
+
+func (opts *options) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	response, code := getErrorResponse(r, err, opts.errorHandlers)
+
+	span, ok := spanFromContext(r.Context())
+	if !ok {
+		opts.responseWriter.WriteError(r, w, response, code)
+		return
+	}
+
+	start := time.Now()
+	opts.responseWriter.WriteError(r, w, response, code)
+
+	span.RecordError(err)
+	span.AddEvent("write", "duration", time.Since(start))
+}
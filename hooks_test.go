@@ -0,0 +1,105 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hooks", func() {
+	It("with Use option", func() {
+		var calls []string
+		middleware := func(name string) func(http.Handler) http.Handler {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+		h := func(r *http.Request) (string, error) {
+			calls = append(calls, "handle")
+			return "success", nil
+		}
+
+		w := httptest.NewRecorder()
+		controller.Respond[string](h).
+			With(controller.Use(middleware("first"), middleware("second"))).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal([]string{"first", "second", "handle"}))
+	})
+
+	It("with BeforeHandle and AfterHandle options", func() {
+		var (
+			called    bool
+			afterErr  error
+			afterCall int
+		)
+		h := func(r *http.Request) (string, error) {
+			called = true
+			return "success", nil
+		}
+
+		w := httptest.NewRecorder()
+		controller.Respond[string](h).
+			With(
+				controller.BeforeHandle(func(r *http.Request) error {
+					return &testError{Detail: "denied"}
+				}),
+				controller.AfterHandle(func(r *http.Request, result any, err error) {
+					afterCall++
+					afterErr = err
+				}),
+				controller.ErrorWithCode[*testError](http.StatusUnauthorized),
+			).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(called).To(BeFalse())
+		Expect(afterCall).To(Equal(1))
+		Expect(afterErr).To(MatchError("denied"))
+	})
+
+	It("with AfterHandle option receiving result", func() {
+		var afterResult any
+		h := func(r *http.Request) (string, error) {
+			return "success", nil
+		}
+
+		controller.Respond[string](h).
+			With(
+				controller.AfterHandle(func(r *http.Request, result any, err error) {
+					afterResult = result
+				}),
+			).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(afterResult).To(Equal("success"))
+	})
+
+	It("with OnPanic option", func() {
+		var recovered *controller.RecoveredError
+		h := func(r *http.Request) (string, error) {
+			panic(errors.New("boom"))
+		}
+
+		w := httptest.NewRecorder()
+		controller.Respond[string](h).
+			With(
+				controller.OnPanic(func(r *http.Request, err *controller.RecoveredError) {
+					recovered = err
+				}),
+			).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(recovered.Panic).To(MatchError("boom"))
+	})
+})
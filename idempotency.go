@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentResponse is response stored by IdempotencyStore.
+type IdempotentResponse struct {
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore keeps responses of requests with Idempotency-Key.
+type IdempotencyStore interface {
+	// Lock acquires key.
+	// If response for key was already saved it is returned and acquired is false.
+	// If key is held by other request both response is nil and acquired is false.
+	Lock(ctx context.Context, key string) (response *IdempotentResponse, acquired bool, err error)
+	// Save stores response and releases key.
+	Save(ctx context.Context, key string, response *IdempotentResponse) error
+	// Unlock releases key without storing response.
+	Unlock(ctx context.Context, key string) error
+}
+
+// If request with the same Idempotency-Key is still in progress - IdempotencyConflictError is returned.
+type IdempotencyConflictError struct {
+	Key string
+}
+
+func (err *IdempotencyConflictError) Error() string {
+	return fmt.Sprintf("request with Idempotency-Key %q is being processed", err.Key)
+}
+
+// If Idempotency-Key was used with different payload - IdempotencyMismatchError is returned.
+type IdempotencyMismatchError struct {
+	Key string
+}
+
+func (err *IdempotencyMismatchError) Error() string {
+	return fmt.Sprintf("Idempotency-Key %q was used with different payload", err.Key)
+}
+
+// IdempotencyConfig configures Idempotent option.
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// Scope identifies client Idempotency-Key belongs to,
+	// so that clients using the same key do not get responses of each other.
+	// Authorization header is used if nil.
+	Scope func(*http.Request) string
+}
+
+// Idempotent makes unsafe requests with Idempotency-Key header safe to retry.
+// First response with status code below 500 is stored and replayed for repeated requests
+// of the same client identified by Authorization header,
+// requests without Idempotency-Key are processed as usual.
+func Idempotent(store IdempotencyStore) func(Options) {
+	return IdempotentWith(IdempotencyConfig{Store: store})
+}
+
+// IdempotentWith is Idempotent with full configuration.
+func IdempotentWith(config IdempotencyConfig) func(Options) {
+	return func(o Options) { o.Idempotent(config) }
+}
+
+func (opts *options) idempotencyHandler(next http.Handler) http.Handler {
+	if opts.idempotency == nil || opts.idempotency.Store == nil {
+		return next
+	}
+
+	store := opts.idempotency.Store
+
+	scope := opts.idempotency.Scope
+	if scope == nil {
+		scope = func(r *http.Request) string { return r.Header.Get("Authorization") }
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.Trim(r.Header.Get(idempotencyKeyHeader), `"`)
+		if key == "" || isSafeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			opts.writeError(w, r, &ReadRequestError{err: err})
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(b))
+
+		fingerprint := sha256.Sum256(b)
+		client := sha256.Sum256([]byte(scope(r)))
+		storeKey := r.Method + " " + r.URL.Path + " " + hex.EncodeToString(client[:]) + " " + key
+		response := &IdempotentResponse{Fingerprint: hex.EncodeToString(fingerprint[:])}
+
+		stored, acquired, err := store.Lock(r.Context(), storeKey)
+		switch {
+		case err != nil:
+			logger().Error("request failed: failed to acquire Idempotency-Key", "error", err)
+			opts.writeError(w, r, err)
+
+			return
+		case stored != nil && stored.Fingerprint != response.Fingerprint:
+			opts.writeError(w, r, &IdempotencyMismatchError{Key: key})
+			return
+		case stored != nil:
+			copyHeader(w.Header(), stored.Header)
+			w.WriteHeader(stored.StatusCode)
+
+			if _, err := w.Write(stored.Body); err != nil {
+				logger().Error("failed to write response", "error", err)
+			}
+
+			return
+		case !acquired:
+			opts.writeError(w, r, &IdempotencyConflictError{Key: key})
+			return
+		}
+
+		buf := newResponseBuffer(make(http.Header))
+		slot := &lateResponseSlot{}
+		ctx := context.WithoutCancel(r.Context())
+
+		returned := false
+
+		defer func() {
+			if !returned {
+				if err := store.Unlock(ctx, storeKey); err != nil {
+					logger().Error("failed to release Idempotency-Key", "error", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(buf, r.WithContext(context.WithValue(r.Context(), lateResponseKey{}, slot)))
+		returned = true
+
+		if late := slot.late; late != nil {
+			// handler is still running after timeout, key is held until it returns
+			// so that retry does not execute it again
+			go func() {
+				<-late.finished
+
+				if late.panicked {
+					late.buf.code = http.StatusInternalServerError
+				}
+
+				saveIdempotentResponse(ctx, store, storeKey, response, late.buf)
+			}()
+		} else {
+			saveIdempotentResponse(ctx, store, storeKey, response, buf)
+		}
+
+		buf.flush(w)
+	})
+}
+
+// saveIdempotentResponse stores response with status code below 500 and releases key otherwise.
+func saveIdempotentResponse(
+	ctx context.Context,
+	store IdempotencyStore,
+	key string,
+	response *IdempotentResponse,
+	buf *responseBuffer,
+) {
+	if buf.status() < http.StatusInternalServerError {
+		response.StatusCode = buf.status()
+		response.Header = buf.Header().Clone()
+		response.Body = bytes.Clone(buf.body.Bytes())
+
+		err := store.Save(ctx, key, response)
+		if err == nil {
+			return
+		}
+
+		logger().Error("failed to save idempotent response", "error", err)
+	}
+
+	if err := store.Unlock(ctx, key); err != nil {
+		logger().Error("failed to release Idempotency-Key", "error", err)
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// MemoryIdempotencyStore is in-memory IdempotencyStore
+// that keeps responses and locks for ttl.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	response  *IdempotentResponse
+	expiresAt time.Time
+}
+
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:       ttl,
+		entries:   make(map[string]*idempotencyEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Lock(_ context.Context, key string) (*IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		return entry.response, false, nil
+	}
+
+	s.entries[key] = &idempotencyEntry{expiresAt: now.Add(s.ttl)}
+
+	return nil, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, response *IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &idempotencyEntry{response: response, expiresAt: time.Now().Add(s.ttl)}
+
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.response == nil {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// sweep removes expired entries at most once per ttl.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,221 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Idempotent", func() {
+	var (
+		calls  atomic.Int32
+		action http.Handler
+	)
+
+	BeforeEach(func() {
+		calls.Store(0)
+
+		h := func(r *http.Request) (string, error) {
+			greet, err := controller.ReadJSON[string](r)
+			if err != nil {
+				return "", err
+			}
+
+			calls.Add(1)
+
+			return *greet, nil
+		}
+
+		action = controller.Respond[string](h).
+			With(
+				controller.SuccessCode(http.StatusCreated),
+				controller.Idempotent(controller.NewMemoryIdempotencyStore(time.Minute)),
+				controller.Use(func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("X-Call", "yes")
+						next.ServeHTTP(w, r)
+					})
+				}),
+			)
+	})
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("replays stored response", func() {
+		first := post(`"key-1"`, `"pay"`)
+		second := post(`"key-1"`, `"pay"`)
+
+		Expect(calls.Load()).To(Equal(int32(1)))
+		Expect(first.Code).To(Equal(http.StatusCreated))
+		Expect(second.Code).To(Equal(http.StatusCreated))
+		Expect(second.Header().Get("Content-Type")).To(Equal("application/json; charset=utf-8"))
+		Expect(second.Header().Get("X-Call")).To(Equal("yes"))
+		Expect(second.Body.String()).To(Equal(first.Body.String()))
+	})
+
+	It("rejects reused key with different payload", func() {
+		post("key-2", `"pay"`)
+		w := post("key-2", `"refund"`)
+
+		Expect(calls.Load()).To(Equal(int32(1)))
+		Expect(w.Code).To(Equal(http.StatusUnprocessableEntity))
+	})
+
+	It("processes requests without key", func() {
+		post("", `"pay"`)
+		post("", `"pay"`)
+
+		Expect(calls.Load()).To(Equal(int32(2)))
+	})
+
+	It("does not store server errors", func() {
+		store := controller.NewMemoryIdempotencyStore(time.Minute)
+		fail := true
+		h := func(r *http.Request) (string, error) {
+			if fail {
+				fail = false
+				return "", &testError{Detail: "oops"}
+			}
+
+			return "success", nil
+		}
+		action := controller.Respond[string](h).With(controller.Idempotent(store))
+
+		for _, code := range []int{http.StatusInternalServerError, http.StatusOK} {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+			r.Header.Set("Idempotency-Key", "key-3")
+
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, r)
+
+			Expect(w.Code).To(Equal(code))
+		}
+	})
+
+	It("rejects concurrent duplicate", func() {
+		started, release := make(chan struct{}), make(chan struct{})
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			close(started)
+			<-release
+
+			return "success", nil
+		}).With(controller.Idempotent(controller.NewMemoryIdempotencyStore(time.Minute)))
+
+		serve := func() *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+			r.Header.Set("Idempotency-Key", "key-4")
+
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, r)
+
+			return w
+		}
+
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() { done <- serve() }()
+
+		<-started
+
+		Expect(serve().Code).To(Equal(http.StatusConflict))
+
+		close(release)
+
+		Expect((<-done).Code).To(Equal(http.StatusOK))
+	})
+
+	It("scopes keys to client", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "secret for " + r.Header.Get("Authorization"), nil
+		}).With(controller.Idempotent(controller.NewMemoryIdempotencyStore(time.Minute)))
+
+		serve := func(authorization string) string {
+			r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+			r.Header.Set("Idempotency-Key", "key-5")
+			r.Header.Set("Authorization", authorization)
+
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, r)
+
+			return w.Body.String()
+		}
+
+		Expect(serve("admin")).To(Equal("\"secret for admin\"\n"))
+		Expect(serve("bob")).To(Equal("\"secret for bob\"\n"))
+	})
+
+	It("scopes keys with configured function", func() {
+		calls := 0
+		action := controller.Respond[int](func(r *http.Request) (int, error) {
+			calls++
+			return calls, nil
+		}).With(controller.IdempotentWith(controller.IdempotencyConfig{
+			Store: controller.NewMemoryIdempotencyStore(time.Minute),
+			Scope: func(r *http.Request) string { return r.Header.Get("X-Tenant") },
+		}))
+
+		serve := func(tenant, authorization string) string {
+			r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+			r.Header.Set("Idempotency-Key", "key-6")
+			r.Header.Set("X-Tenant", tenant)
+			r.Header.Set("Authorization", authorization)
+
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, r)
+
+			return w.Body.String()
+		}
+
+		Expect(serve("a", "token-1")).To(Equal("1\n"))
+		Expect(serve("a", "token-2")).To(Equal("1\n"))
+		Expect(serve("b", "token-1")).To(Equal("2\n"))
+	})
+
+	It("holds key of handler running after timeout", func() {
+		release := make(chan struct{})
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			calls.Add(1)
+			<-release
+
+			return "charged", nil
+		}).With(
+			controller.Idempotent(controller.NewMemoryIdempotencyStore(time.Minute)),
+			controller.Timeout(10*time.Millisecond),
+		)
+
+		serve := func() *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+			r.Header.Set("Idempotency-Key", "key-7")
+
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, r)
+
+			return w
+		}
+
+		Expect(serve().Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(serve().Code).To(Equal(http.StatusConflict))
+
+		close(release)
+
+		Eventually(func() int { return serve().Code }).Should(Equal(http.StatusOK))
+		Expect(serve().Body.String()).To(Equal("\"charged\"\n"))
+		Expect(calls.Load()).To(Equal(int32(1)))
+	})
+})
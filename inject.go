@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Lifetime defines how long provided value lives.
+type Lifetime int
+
+const (
+	// Singleton value is provided once and shared by all requests.
+	Singleton Lifetime = iota
+	// Scoped value is provided once per request.
+	Scoped
+	// Transient value is provided every time it is resolved.
+	Transient
+)
+
+// If dependency can not be resolved - DependencyError is returned.
+type DependencyError struct {
+	Type reflect.Type
+	err  error
+}
+
+func (err *DependencyError) Error() string {
+	return fmt.Sprintf("failed to resolve %s: %s", err.Type, err.err)
+}
+
+func (err *DependencyError) Unwrap() error {
+	return err.err
+}
+
+// Container holds providers of dependencies registered by type.
+type Container struct {
+	mu        sync.RWMutex
+	providers map[reflect.Type]*provider
+}
+
+type provider struct {
+	lifetime Lifetime
+	provide  func(*http.Request) (any, error)
+	cleanup  func(any, error)
+
+	mu        sync.Mutex
+	built     bool
+	singleton any
+}
+
+func NewContainer() *Container {
+	return &Container{providers: make(map[reflect.Type]*provider)}
+}
+
+// Provide registers provider of T with lifetime.
+func Provide[T any](c *Container, lifetime Lifetime, provide func(*http.Request) (T, error)) {
+	ProvideWithCleanup(c, lifetime, provide, nil)
+}
+
+// ProvideWithCleanup registers provider of T with lifetime.
+// Scoped and Transient values are cleaned up after response was written,
+// err is the error handler returned, *RecoveredError if it panicked
+// or request context error if context was done before handler returned, e.g. after Timeout.
+func ProvideWithCleanup[T any](
+	c *Container,
+	lifetime Lifetime,
+	provide func(*http.Request) (T, error),
+	cleanup func(value T, err error),
+) {
+	p := &provider{
+		lifetime: lifetime,
+		provide:  func(r *http.Request) (any, error) { return provide(r) },
+	}
+
+	if cleanup != nil {
+		p.cleanup = func(value any, err error) { cleanup(value.(T), err) }
+	}
+
+	c.mu.Lock()
+	c.providers[reflect.TypeFor[T]()] = p
+	c.mu.Unlock()
+}
+
+// Resolve returns T provided for request by Container set with WithContainer option.
+func Resolve[T any](r *http.Request) (T, error) {
+	value, err := resolve(r, reflect.TypeFor[T]())
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	t, _ := value.(T)
+
+	return t, nil
+}
+
+// WithContainer sets Container dependencies are resolved from.
+func WithContainer(c *Container) func(Options) {
+	return func(o Options) { o.Container(c) }
+}
+
+// Inject is handler that receives dependencies resolved from Container.
+// Deps is either registered type or struct which exported fields are resolved by their types,
+// fields tagged with `inject:"-"` are skipped.
+type Inject[Deps, T any] func(*http.Request, Deps) (T, error)
+
+// With allows change default Inject behaviour with options.
+func (handle Inject[Deps, T]) With(c *Container, opts ...func(Options)) http.Handler {
+	options := newOptions()
+	for _, option := range append([]func(Options){WithContainer(c)}, opts...) {
+		option(options)
+	}
+
+	return handle.getHttpHandle(options)
+}
+
+func (handle Inject[Deps, T]) getHttpHandle(opts *options) http.Handler {
+	return opts.handler(handle.getHandleFunc(opts))
+}
+
+func (handle Inject[Deps, T]) getHandleFunc(opts *options) handleFunc {
+	return opts.authorized(func(r *http.Request) (any, error) {
+		deps, err := resolveDeps[Deps](r)
+		if err != nil {
+			return nil, err
+		}
+
+		return handle(r, deps)
+	})
+}
+
+// WithContainer resolves U from Container for every request instead of using value fixed at registration.
+func (decorated DecoratedResponse[T, U]) WithContainer(c *Container, opts ...func(Options)) http.Handler {
+	return Inject[U, T](func(r *http.Request, u U) (T, error) { return decorated(u)(r) }).With(c, opts...)
+}
+
+func resolveDeps[Deps any](r *http.Request) (Deps, error) {
+	var deps Deps
+
+	s, ok := r.Context().Value(scopeKey{}).(*scope)
+	t := reflect.TypeFor[Deps]()
+
+	if !ok || s.container.has(t) || t.Kind() != reflect.Struct {
+		return Resolve[Deps](r)
+	}
+
+	v := reflect.ValueOf(&deps).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("inject") == "-" {
+			continue
+		}
+
+		value, err := s.resolve(r, field.Type)
+		if err != nil {
+			return deps, err
+		}
+
+		if value != nil {
+			v.Field(i).Set(reflect.ValueOf(value))
+		}
+	}
+
+	return deps, nil
+}
+
+func (c *Container) has(t reflect.Type) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.providers[t]
+
+	return ok
+}
+
+func (c *Container) provider(t reflect.Type) (*provider, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.providers[t]
+
+	return p, ok
+}
+
+type scopeKey struct{}
+
+// scope holds Scoped values and cleanups of single request.
+type scope struct {
+	container *Container
+
+	mu       sync.Mutex
+	values   map[reflect.Type]any
+	cleanups []func(error)
+	err      error
+}
+
+func (opts *options) scopeHandler(next http.Handler) http.Handler {
+	if opts.container == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := &scope{container: opts.container, values: make(map[reflect.Type]any)}
+		defer s.close(r.Context())
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, s)))
+	})
+}
+
+func resolve(r *http.Request, t reflect.Type) (any, error) {
+	s, ok := r.Context().Value(scopeKey{}).(*scope)
+	if !ok {
+		return nil, &DependencyError{Type: t, err: fmt.Errorf("handler has no Container")}
+	}
+
+	return s.resolve(r, t)
+}
+
+func (s *scope) resolve(r *http.Request, t reflect.Type) (any, error) {
+	p, ok := s.container.provider(t)
+	if !ok {
+		return nil, &DependencyError{Type: t, err: fmt.Errorf("no provider registered")}
+	}
+
+	switch p.lifetime {
+	case Singleton:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if !p.built {
+			value, err := p.provide(r)
+			if err != nil {
+				return nil, err
+			}
+
+			p.singleton, p.built = value, true
+		}
+
+		return p.singleton, nil
+	case Scoped:
+		s.mu.Lock()
+		value, ok := s.values[t]
+		s.mu.Unlock()
+
+		if ok {
+			return value, nil
+		}
+
+		value, err := s.build(r, p)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.values[t] = value
+		s.mu.Unlock()
+
+		return value, nil
+	default:
+		return s.build(r, p)
+	}
+}
+
+func (s *scope) build(r *http.Request, p *provider) (any, error) {
+	value, err := p.provide(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.cleanup != nil {
+		s.mu.Lock()
+		s.cleanups = append(s.cleanups, func(err error) { p.cleanup(value, err) })
+		s.mu.Unlock()
+	}
+
+	return value, nil
+}
+
+// close runs cleanups in reverse order of values creation.
+// If handler did not fail, but ctx is done, cleanups receive ctx error.
+func (s *scope) close(ctx context.Context) {
+	s.mu.Lock()
+	cleanups, err := s.cleanups, s.err
+	s.mu.Unlock()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		func() {
+			defer func() {
+				if rp := recover(); rp != nil {
+					logger().Error("dependency cleanup failed: recovered from panic", "error", rp)
+				}
+			}()
+
+			cleanups[i](err)
+		}()
+	}
+}
+
+// failScope records error handler failed with for cleanups of request scope.
+func failScope(r *http.Request, err error) {
+	if s, ok := r.Context().Value(scopeKey{}).(*scope); ok {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,175 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testTx struct {
+	ID int
+}
+
+type testClock struct {
+	Now string
+}
+
+type testDeps struct {
+	Tx      *testTx
+	Clock   testClock
+	Skipped *testTx `inject:"-"`
+}
+
+var _ = Describe("Inject", func() {
+	var (
+		c        *controller.Container
+		built    int
+		cleaned  []error
+		clockNow int
+	)
+
+	BeforeEach(func() {
+		built, clockNow = 0, 0
+		cleaned = nil
+
+		c = controller.NewContainer()
+		controller.ProvideWithCleanup(c, controller.Scoped,
+			func(r *http.Request) (*testTx, error) {
+				built++
+				return &testTx{ID: built}, nil
+			},
+			func(tx *testTx, err error) { cleaned = append(cleaned, err) },
+		)
+		controller.Provide(c, controller.Singleton, func(r *http.Request) (testClock, error) {
+			clockNow++
+			return testClock{Now: "noon"}, nil
+		})
+	})
+
+	serve := func(action http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return w
+	}
+
+	It("resolves scoped value once per request", func() {
+		action := controller.Respond[int](func(r *http.Request) (int, error) {
+			first, err := controller.Resolve[*testTx](r)
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := controller.Resolve[*testTx](r)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(BeIdenticalTo(first))
+
+			return first.ID, nil
+		}).With(controller.WithContainer(c))
+
+		Expect(serve(action).Body.String()).To(Equal("1\n"))
+		Expect(serve(action).Body.String()).To(Equal("2\n"))
+		Expect(cleaned).To(Equal([]error{nil, nil}))
+	})
+
+	It("resolves transient value every time", func() {
+		controller.Provide(c, controller.Transient, func(r *http.Request) (*testTx, error) {
+			built++
+			return &testTx{ID: built}, nil
+		})
+
+		action := controller.Respond[int](func(r *http.Request) (int, error) {
+			first, _ := controller.Resolve[*testTx](r)
+			second, _ := controller.Resolve[*testTx](r)
+
+			return first.ID + second.ID, nil
+		}).With(controller.WithContainer(c))
+
+		Expect(serve(action).Body.String()).To(Equal("3\n"))
+	})
+
+	It("injects struct fields", func() {
+		action := controller.Inject[testDeps, string](func(r *http.Request, deps testDeps) (string, error) {
+			Expect(deps.Skipped).To(BeNil())
+
+			return deps.Clock.Now, nil
+		}).With(c)
+
+		Expect(serve(action).Body.String()).To(Equal("\"noon\"\n"))
+		Expect(serve(action).Body.String()).To(Equal("\"noon\"\n"))
+		Expect(clockNow).To(Equal(1))
+		Expect(built).To(Equal(2))
+	})
+
+	It("passes handler error to cleanup", func() {
+		failure := errors.New("boom")
+		action := controller.Inject[*testTx, string](func(r *http.Request, tx *testTx) (string, error) {
+			return "", failure
+		}).With(c)
+
+		Expect(serve(action).Code).To(Equal(http.StatusInternalServerError))
+		Expect(cleaned).To(HaveLen(1))
+		Expect(cleaned[0]).To(MatchError(failure))
+	})
+
+	It("passes recovered panic to cleanup", func() {
+		action := controller.Inject[*testTx, string](func(r *http.Request, tx *testTx) (string, error) {
+			panic("boom")
+		}).With(c)
+
+		Expect(serve(action).Code).To(Equal(http.StatusInternalServerError))
+		Expect(cleaned).To(HaveLen(1))
+
+		var recovered *controller.RecoveredError
+
+		Expect(errors.As(cleaned[0], &recovered)).To(BeTrue())
+	})
+
+	It("passes context error to cleanup after timeout", func() {
+		cleanups := make(chan error, 1)
+		c := controller.NewContainer()
+		controller.ProvideWithCleanup(c, controller.Scoped,
+			func(r *http.Request) (*testTx, error) { return &testTx{ID: 1}, nil },
+			func(tx *testTx, err error) { cleanups <- err },
+		)
+
+		action := controller.Inject[*testTx, string](func(r *http.Request, tx *testTx) (string, error) {
+			<-r.Context().Done()
+			return "late", nil
+		}).With(c, controller.Timeout(10*time.Millisecond))
+
+		Expect(serve(action).Code).To(Equal(http.StatusServiceUnavailable))
+
+		var err error
+
+		Eventually(cleanups).Should(Receive(&err))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("resolves decorated response dependency per request", func() {
+		var h controller.DecoratedResponse[string, *testTx] = func(tx *testTx) controller.Respond[string] {
+			return func(r *http.Request) (string, error) {
+				return strings.Repeat("tx", tx.ID), nil
+			}
+		}
+
+		action := h.WithContainer(c)
+
+		Expect(serve(action).Body.String()).To(Equal("\"tx\"\n"))
+		Expect(serve(action).Body.String()).To(Equal("\"txtx\"\n"))
+	})
+
+	It("fails if dependency is not registered", func() {
+		action := controller.Inject[*testClock, string](func(r *http.Request, clock *testClock) (string, error) {
+			return clock.Now, nil
+		}).With(c)
+
+		Expect(serve(action).Code).To(Equal(http.StatusInternalServerError))
+	})
+})
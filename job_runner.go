@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// JobState is the state of background job.
+type JobState string
+
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// JobStatus is the status of background job.
+type JobStatus struct {
+	ID        string    `json:"id"`
+	State     JobState  `json:"state"`
+	Result    any       `json:"-"`
+	Err       error     `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// JobRunner executes Async jobs in background.
+type JobRunner interface {
+	// Submit enqueues job, ctx carries request values and is not cancelled with request.
+	// MemoryJobRunner returns *JobRunnerClosedError after it was closed.
+	Submit(ctx context.Context, job func(context.Context) (any, error)) (JobStatus, error)
+	// Status returns *JobNotFoundError if job is not known.
+	Status(ctx context.Context, id string) (JobStatus, error)
+	// Cancel returns *JobNotFoundError if job is not known.
+	Cancel(ctx context.Context, id string) error
+}
+
+// MemoryJobRunner is in-memory JobRunner with bounded worker pool and queue.
+// Finished jobs are kept for ttl.
+type MemoryJobRunner struct {
+	ttl   time.Duration
+	queue chan *memoryJob
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu        sync.Mutex
+	jobs      map[string]*memoryJob
+	lastSweep time.Time
+	closed    bool
+}
+
+type memoryJob struct {
+	status JobStatus
+	ctx    context.Context
+	cancel context.CancelFunc
+	run    func(context.Context) (any, error)
+}
+
+func NewMemoryJobRunner(workers, queueSize int, ttl time.Duration) *MemoryJobRunner {
+	runner := &MemoryJobRunner{
+		ttl:       ttl,
+		queue:     make(chan *memoryJob, queueSize),
+		done:      make(chan struct{}),
+		jobs:      make(map[string]*memoryJob),
+		lastSweep: time.Now(),
+	}
+
+	runner.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go runner.work()
+	}
+
+	return runner
+}
+
+func (runner *MemoryJobRunner) Submit(ctx context.Context, run func(context.Context) (any, error)) (JobStatus, error) {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+
+	now := time.Now()
+	job := &memoryJob{
+		status: JobStatus{ID: hex.EncodeToString(id[:]), State: JobPending, CreatedAt: now, UpdatedAt: now},
+		run:    run,
+	}
+	job.ctx, job.cancel = context.WithCancel(ctx)
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	if runner.closed {
+		job.cancel()
+		return JobStatus{}, &JobRunnerClosedError{}
+	}
+
+	runner.sweep(now)
+
+	select {
+	case runner.queue <- job:
+		runner.jobs[job.status.ID] = job
+		return job.status, nil
+	default:
+		job.cancel()
+		return JobStatus{}, &OverloadedError{RetryAfter: time.Second}
+	}
+}
+
+func (runner *MemoryJobRunner) Status(_ context.Context, id string) (JobStatus, error) {
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	job, ok := runner.jobs[id]
+	if !ok {
+		return JobStatus{}, &JobNotFoundError{ID: id}
+	}
+
+	return job.status, nil
+}
+
+func (runner *MemoryJobRunner) Cancel(_ context.Context, id string) error {
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	job, ok := runner.jobs[id]
+	if !ok {
+		return &JobNotFoundError{ID: id}
+	}
+
+	if job.status.State == JobPending || job.status.State == JobRunning {
+		job.cancel()
+		runner.update(job, JobCancelled, nil, nil)
+	}
+
+	return nil
+}
+
+// Close stops workers after running jobs finish, pending jobs are cancelled.
+// Jobs can not be submitted after Close, subsequent calls do nothing.
+func (runner *MemoryJobRunner) Close() {
+	runner.mu.Lock()
+	if runner.closed {
+		runner.mu.Unlock()
+		return
+	}
+
+	runner.closed = true
+	runner.mu.Unlock()
+
+	close(runner.done)
+	runner.wg.Wait()
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	for _, job := range runner.jobs {
+		if job.status.State == JobPending {
+			job.cancel()
+			runner.update(job, JobCancelled, nil, nil)
+		}
+	}
+}
+
+func (runner *MemoryJobRunner) work() {
+	defer runner.wg.Done()
+
+	for {
+		select {
+		case <-runner.done:
+			return
+		case job := <-runner.queue:
+			runner.execute(job)
+		}
+	}
+}
+
+func (runner *MemoryJobRunner) execute(job *memoryJob) {
+	runner.mu.Lock()
+	if job.status.State != JobPending {
+		runner.mu.Unlock()
+		return
+	}
+
+	runner.update(job, JobRunning, nil, nil)
+	runner.mu.Unlock()
+
+	var (
+		result any
+		err    error
+	)
+
+	func() {
+		defer func() {
+			if rp := recover(); rp != nil {
+				stack := debug.Stack()
+				err = newRecoveredError(rp, stack)
+
+				logger().Error("job failed: recovered from panic during job", "error", err, "stack", string(stack))
+			}
+		}()
+
+		result, err = job.run(job.ctx)
+	}()
+
+	job.cancel()
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	if job.status.State != JobRunning {
+		return
+	}
+
+	if err != nil {
+		runner.update(job, JobFailed, nil, err)
+		return
+	}
+
+	runner.update(job, JobSucceeded, result, nil)
+}
+
+// update must be called with runner.mu held.
+func (runner *MemoryJobRunner) update(job *memoryJob, state JobState, result any, err error) {
+	job.status.State = state
+	job.status.Result = result
+	job.status.Err = err
+	job.status.UpdatedAt = time.Now()
+}
+
+// sweep removes jobs finished more than ttl ago at most once per ttl, must be called with runner.mu held.
+func (runner *MemoryJobRunner) sweep(now time.Time) {
+	if now.Sub(runner.lastSweep) < runner.ttl {
+		return
+	}
+
+	for id, job := range runner.jobs {
+		finished := job.status.State != JobPending && job.status.State != JobRunning
+		if finished && now.Sub(job.status.UpdatedAt) >= runner.ttl {
+			delete(runner.jobs, id)
+		}
+	}
+
+	runner.lastSweep = now
+}
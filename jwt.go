@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWKS is JSON Web Key Set with RSA, Ed25519 and symmetric keys.
+type JWKS struct {
+	keys []jwk
+}
+
+type jwk struct {
+	id  string
+	alg string
+	key any
+}
+
+// ReadJWKS reads JSON Web Key Set from file.
+// Keys of types other than RSA, OKP with Ed25519 curve and oct are skipped.
+func ReadJWKS(path string) (*JWKS, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(b)
+}
+
+// ParseJWKS parses JSON Web Key Set.
+func ParseJWKS(b []byte) (*JWKS, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	jwks := &JWKS{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key := jwk{id: k.Kid}
+
+		switch {
+		case k.Kty == "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+
+			key.alg = "RS256"
+			key.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "OKP" && k.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("key %q: invalid Ed25519 public key", k.Kid)
+			}
+
+			key.alg = "EdDSA"
+			key.key = ed25519.PublicKey(x)
+		case k.Kty == "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+
+			key.alg = "HS256"
+			key.key = secret
+		default:
+			continue
+		}
+
+		if k.Alg != "" && k.Alg != key.alg {
+			continue
+		}
+
+		jwks.keys = append(jwks.keys, key)
+	}
+
+	return jwks, nil
+}
+
+// JWTConfig configures JWT authenticator.
+type JWTConfig struct {
+	// Secret verifies HS256 tokens.
+	Secret []byte
+	// Keys verify HS256, RS256 and EdDSA tokens, key is selected by token "kid" header if it is set.
+	Keys *JWKS
+	// Issuer is required "iss" claim if set.
+	Issuer string
+	// Audience is required to be in "aud" claim if set.
+	Audience string
+	// Leeway is allowed clock skew for "exp" and "nbf" claims.
+	Leeway time.Duration
+}
+
+// JWT authenticates request with JSON Web Token from "Authorization: Bearer <token>" header.
+// Tokens signed with HS256, RS256 and EdDSA are accepted, principal is decoded from token claims.
+func JWT[P any](config JWTConfig) Authenticator[P] {
+	return func(r *http.Request) (P, error) {
+		var principal P
+
+		token, ok := bearerToken(r)
+		if !ok {
+			return principal, &UnauthorizedError{Challenge: "Bearer", err: errors.New("bearer token is missing")}
+		}
+
+		payload, err := config.verify(token, time.Now())
+		if err != nil {
+			return principal, &UnauthorizedError{Challenge: `Bearer error="invalid_token"`, err: err}
+		}
+
+		if err := json.Unmarshal(payload, &principal); err != nil {
+			return principal, &UnauthorizedError{Challenge: `Bearer error="invalid_token"`, err: err}
+		}
+
+		return principal, nil
+	}
+}
+
+// verify checks token signature and registered claims and returns token payload.
+func (config JWTConfig) verify(token string, now time.Time) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %w", err)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if !slices.ContainsFunc(config.keys(header.Alg, header.Kid), func(key any) bool {
+		return verifyJWTSignature(header.Alg, key, signed, signature)
+	}) {
+		return nil, errors.New("signature is not valid")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed payload: %w", err)
+	}
+
+	var claims struct {
+		Exp *json.Number    `json:"exp"`
+		Nbf *json.Number    `json:"nbf"`
+		Iss string          `json:"iss"`
+		Aud json.RawMessage `json:"aud"`
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("malformed payload: %w", err)
+	}
+
+	if claims.Exp != nil {
+		exp, err := claims.Exp.Float64()
+		if err != nil || now.After(time.Unix(int64(exp), 0).Add(config.Leeway)) {
+			return nil, errors.New("token is expired")
+		}
+	}
+
+	if claims.Nbf != nil {
+		nbf, err := claims.Nbf.Float64()
+		if err != nil || now.Add(config.Leeway).Before(time.Unix(int64(nbf), 0)) {
+			return nil, errors.New("token is not valid yet")
+		}
+	}
+
+	if config.Issuer != "" && claims.Iss != config.Issuer {
+		return nil, fmt.Errorf("issuer %q is not accepted", claims.Iss)
+	}
+
+	if config.Audience != "" && !jwtAudience(claims.Aud, config.Audience) {
+		return nil, errors.New("audience is not accepted")
+	}
+
+	return payload, nil
+}
+
+func (config JWTConfig) keys(alg, kid string) []any {
+	var keys []any
+	if alg == "HS256" && len(config.Secret) > 0 {
+		keys = append(keys, config.Secret)
+	}
+
+	if config.Keys != nil {
+		for _, key := range config.Keys.keys {
+			if key.alg == alg && (kid == "" || key.id == kid) {
+				keys = append(keys, key.key)
+			}
+		}
+	}
+
+	return keys
+}
+
+func verifyJWTSignature(alg string, key any, signed, signature []byte) bool {
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+
+		return hmac.Equal(mac.Sum(nil), signature)
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+
+		digest := sha256.Sum256(signed)
+
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+
+		return ed25519.Verify(pub, signed, signature)
+	default:
+		return false
+	}
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+
+	return nil
+}
+
+func jwtAudience(raw json.RawMessage, audience string) bool {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one == audience
+	}
+
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		return slices.Contains(many, audience)
+	}
+
+	return false
+}
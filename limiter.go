@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// If handler has no capacity left - OverloadedError is returned.
+type OverloadedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *OverloadedError) Error() string {
+	return fmt.Sprintf("too many requests in flight, retry after %s", err.RetryAfter)
+}
+
+// LimiterConfig configures MaxInFlight option.
+type LimiterConfig struct {
+	// MaxInFlight is maximal number of requests handled concurrently.
+	// It is initial limit if Adaptive is set.
+	MaxInFlight int
+	// QueueSize is maximal number of requests waiting for capacity.
+	// Requests are rejected immediately if 0.
+	QueueSize int
+	// QueueTimeout is maximal time request waits in queue.
+	// Requests wait until capacity is available or request is cancelled if 0.
+	QueueTimeout time.Duration
+	// RetryAfter is sent in Retry-After header of rejected response.
+	// 1 second is used if 0.
+	RetryAfter time.Duration
+	// Adaptive adjusts limit to observed latency if set.
+	Adaptive *AdaptiveLimit
+}
+
+// AdaptiveLimit is additive-increase/multiplicative-decrease limit.
+// Limit is decreased by Backoff every time request is slower than LatencyThreshold
+// and increased by one when request is fast while at least half of capacity is used.
+type AdaptiveLimit struct {
+	MinLimit         int
+	MaxLimit         int
+	LatencyThreshold time.Duration
+	// Backoff is multiplier applied to limit on slow request.
+	// 0.9 is used if 0.
+	Backoff float64
+}
+
+// MaxInFlight limits number of concurrently handled requests to n
+// and rejects requests over the limit with 503 Service Unavailable
+// and Retry-After header before request body is read.
+func MaxInFlight(n int) func(Options) {
+	return MaxInFlightWith(LimiterConfig{MaxInFlight: n})
+}
+
+// MaxInFlightWith is MaxInFlight with full configuration.
+func MaxInFlightWith(config LimiterConfig) func(Options) {
+	return func(o Options) { o.MaxInFlight(config) }
+}
+
+func (opts *options) limitHandler(next http.Handler) http.Handler {
+	if opts.limiter == nil || opts.limiter.MaxInFlight <= 0 {
+		return next
+	}
+
+	l := newLimiter(*opts.limiter)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := l.acquire(r); err != nil {
+			if overloaded, ok := err.(*OverloadedError); ok {
+				metrics().Add("controller_requests_rejected_total", 1, "route", r.Pattern, "reason", "max_in_flight")
+				logger().Error("request rejected: handler is overloaded", "route", r.Pattern, "limit", l.currentLimit())
+
+				w.Header().Set("Retry-After", strconv.Itoa(int((overloaded.RetryAfter+time.Second-1)/time.Second)))
+			}
+
+			opts.writeError(w, r, err)
+
+			return
+		}
+
+		start := time.Now()
+		defer func() { l.release(r, time.Since(start)) }()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type limiter struct {
+	LimiterConfig
+
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	queue    list.List
+}
+
+func newLimiter(config LimiterConfig) *limiter {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+
+	if a := config.Adaptive; a != nil {
+		adaptive := *a
+		if adaptive.Backoff <= 0 || adaptive.Backoff >= 1 {
+			adaptive.Backoff = 0.9
+		}
+
+		adaptive.MinLimit = max(adaptive.MinLimit, 1)
+		adaptive.MaxLimit = max(adaptive.MaxLimit, config.MaxInFlight)
+		config.Adaptive = &adaptive
+	}
+
+	return &limiter{LimiterConfig: config, limit: config.MaxInFlight}
+}
+
+func (l *limiter) acquire(r *http.Request) error {
+	l.mu.Lock()
+	if l.inFlight < l.limit {
+		l.inFlight++
+		l.report(r)
+		l.mu.Unlock()
+
+		return nil
+	}
+
+	if l.queue.Len() >= l.QueueSize {
+		l.mu.Unlock()
+		return &OverloadedError{RetryAfter: l.RetryAfter}
+	}
+
+	ready := make(chan struct{})
+	el := l.queue.PushBack(ready)
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if l.QueueTimeout > 0 {
+		timer := time.NewTimer(l.QueueTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-timeout:
+		err = &OverloadedError{RetryAfter: l.RetryAfter}
+	case <-r.Context().Done():
+		err = r.Context().Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// capacity might have been handed over while timing out
+	select {
+	case <-ready:
+		return nil
+	default:
+		l.queue.Remove(el)
+		return err
+	}
+}
+
+func (l *limiter) release(r *http.Request, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.adapt(latency)
+
+	// hand capacity over to waiting requests instead of releasing it
+	if l.inFlight <= l.limit && l.queue.Len() > 0 {
+		close(l.queue.Remove(l.queue.Front()).(chan struct{}))
+	} else {
+		l.inFlight--
+	}
+
+	for l.inFlight < l.limit && l.queue.Len() > 0 {
+		l.inFlight++
+		close(l.queue.Remove(l.queue.Front()).(chan struct{}))
+	}
+
+	l.report(r)
+}
+
+// adapt must be called with l.mu held.
+func (l *limiter) adapt(latency time.Duration) {
+	a := l.Adaptive
+	if a == nil {
+		return
+	}
+
+	switch {
+	case latency > a.LatencyThreshold:
+		l.limit = max(int(float64(l.limit)*a.Backoff), a.MinLimit)
+	case l.inFlight*2 >= l.limit:
+		l.limit = min(l.limit+1, a.MaxLimit)
+	}
+}
+
+// report must be called with l.mu held.
+func (l *limiter) report(r *http.Request) {
+	m := metrics()
+	m.Set("controller_in_flight", float64(l.inFlight), "route", r.Pattern)
+	m.Set("controller_in_flight_limit", float64(l.limit), "route", r.Pattern)
+}
+
+func (l *limiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
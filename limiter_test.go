@@ -0,0 +1,170 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testMetrics struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{values: make(map[string]float64)}
+}
+
+func (m *testMetrics) Add(name string, value float64, _ ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[name] += value
+}
+
+func (m *testMetrics) Set(name string, value float64, _ ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[name] = value
+}
+
+func (m *testMetrics) Get(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.values[name]
+}
+
+var _ = Describe("MaxInFlight", func() {
+	var (
+		metrics *testMetrics
+		started chan struct{}
+		release chan struct{}
+	)
+
+	BeforeEach(func() {
+		metrics = newTestMetrics()
+		controller.SetMetrics(metrics)
+
+		started = make(chan struct{}, 10)
+		release = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		controller.SetMetrics(nil)
+	})
+
+	h := func(r *http.Request) (string, error) {
+		started <- struct{}{}
+		<-release
+
+		return "success", nil
+	}
+
+	serveAsync := func(action http.Handler) <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+			done <- w
+		}()
+
+		return done
+	}
+
+	It("rejects requests over the limit", func() {
+		action := controller.Respond[string](h).With(controller.MaxInFlight(1))
+
+		first := serveAsync(action)
+		Eventually(started).Should(Receive())
+
+		w := <-serveAsync(action)
+
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+		Expect(metrics.Get("controller_requests_rejected_total")).To(Equal(1.0))
+		Expect(metrics.Get("controller_in_flight")).To(Equal(1.0))
+
+		close(release)
+
+		Expect((<-first).Code).To(Equal(http.StatusOK))
+		Expect(metrics.Get("controller_in_flight")).To(Equal(0.0))
+	})
+
+	It("queues requests over the limit", func() {
+		action := controller.Respond[string](h).
+			With(controller.MaxInFlightWith(controller.LimiterConfig{
+				MaxInFlight:  1,
+				QueueSize:    1,
+				QueueTimeout: time.Second,
+			}))
+
+		first := serveAsync(action)
+		Eventually(started).Should(Receive())
+
+		queued := serveAsync(action)
+		Consistently(started, 20*time.Millisecond).ShouldNot(Receive())
+
+		Expect((<-serveAsync(action)).Code).To(Equal(http.StatusServiceUnavailable))
+
+		close(release)
+
+		Expect((<-first).Code).To(Equal(http.StatusOK))
+		Expect((<-queued).Code).To(Equal(http.StatusOK))
+	})
+
+	It("rejects queued requests after queue timeout", func() {
+		action := controller.Respond[string](h).
+			With(controller.MaxInFlightWith(controller.LimiterConfig{
+				MaxInFlight:  1,
+				QueueSize:    1,
+				QueueTimeout: 10 * time.Millisecond,
+				RetryAfter:   5 * time.Second,
+			}))
+
+		first := serveAsync(action)
+		Eventually(started).Should(Receive())
+
+		w := <-serveAsync(action)
+
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Header().Get("Retry-After")).To(Equal("5"))
+
+		close(release)
+
+		Expect((<-first).Code).To(Equal(http.StatusOK))
+	})
+
+	It("decreases adaptive limit on slow requests", func() {
+		close(release)
+
+		slow := func(r *http.Request) (string, error) {
+			time.Sleep(5 * time.Millisecond)
+			return "success", nil
+		}
+		action := controller.Respond[string](slow).
+			With(controller.MaxInFlightWith(controller.LimiterConfig{
+				MaxInFlight: 10,
+				Adaptive: &controller.AdaptiveLimit{
+					MinLimit:         2,
+					MaxLimit:         20,
+					LatencyThreshold: time.Millisecond,
+					Backoff:          0.5,
+				},
+			}))
+
+		Expect((<-serveAsync(action)).Code).To(Equal(http.StatusOK))
+		Expect(metrics.Get("controller_in_flight_limit")).To(Equal(5.0))
+
+		Expect((<-serveAsync(action)).Code).To(Equal(http.StatusOK))
+		Expect((<-serveAsync(action)).Code).To(Equal(http.StatusOK))
+		Expect(metrics.Get("controller_in_flight_limit")).To(Equal(2.0))
+	})
+})
@@ -0,0 +1,37 @@
+package controller
+
+import "sync/atomic"
+
+// Metrics receives metrics reported by handlers.
+// attrs are key-value pairs in the same form Logger accepts them.
+type Metrics interface {
+	// Add increments counter by value.
+	Add(name string, value float64, attrs ...any)
+	// Set sets gauge to value.
+	Set(name string, value float64, attrs ...any)
+}
+
+// SetMetrics sets Metrics used by all handlers.
+// Passing nil restores default no-op Metrics.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		m = noopMetrics{}
+	}
+
+	metricsPtr.Store(&m)
+}
+
+func init() {
+	SetMetrics(nil)
+}
+
+var metricsPtr atomic.Pointer[Metrics]
+
+func metrics() Metrics {
+	return *metricsPtr.Load()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) Add(string, float64, ...any) {}
+func (noopMetrics) Set(string, float64, ...any) {}
@@ -3,18 +3,69 @@ package controller
 import (
 	"errors"
 	"net/http"
+	"time"
 )
 
 type Options interface {
 	SuccessCode(int)
 	ErrorHandlers(...ErrorMatcher)
 	WriteResponse(WriteResponse)
+	Use(...func(http.Handler) http.Handler)
+	BeforeHandle(func(*http.Request) error)
+	AfterHandle(func(*http.Request, any, error))
+	OnPanic(func(*http.Request, *RecoveredError))
+	Timeout(time.Duration)
+	Idempotent(IdempotencyConfig)
+	Coalesce(func(*http.Request) string)
+	Cache(CacheConfig)
+	ETag(current func(*http.Request) (any, error))
+	Compress(CompressConfig)
+	MaxInFlight(LimiterConfig)
+	RateLimit(RateLimitConfig)
+	Breaker(BreakerConfig)
+	Container(*Container)
+	UnitOfWork(UnitOfWork)
+	Deprecation(DeprecationConfig)
+	CORS(CORSConfig)
+	Authenticate(func(*http.Request) (any, error))
+	Authorize(Policy)
+	OnAuthorize(func(*http.Request, AuthorizationDecision))
+	CSRF(CSRFConfig)
+	SecureHeaders(SecureHeadersConfig)
+	VerifySignature(SignatureScheme)
 }
 
 type options struct {
 	responseWriter WriteResponse
 	errorHandlers  []ErrorMatcher
 	successCode    int
+	middlewares    []func(http.Handler) http.Handler
+	beforeHandle   []func(*http.Request) error
+	afterHandle    []func(*http.Request, any, error)
+	onPanic        []func(*http.Request, *RecoveredError)
+	timeout        time.Duration
+	idempotency    *IdempotencyConfig
+	coalesceKey    func(*http.Request) string
+	cache          *CacheConfig
+	etag           *etagConfig
+	compress       *CompressConfig
+	limiter        *LimiterConfig
+	rateLimit      *RateLimitConfig
+	breaker        *BreakerConfig
+	container      *Container
+	unitOfWork     UnitOfWork
+	deprecation    *DeprecationConfig
+	cors           *CORSConfig
+	authenticate   func(*http.Request) (any, error)
+	policy         *Policy
+	onAuthorize    []func(*http.Request, AuthorizationDecision)
+	csrf           *CSRFConfig
+	secureHeaders  *SecureHeadersConfig
+	signature      *SignatureScheme
+}
+
+func newOptions() *options {
+	return &options{successCode: http.StatusOK, responseWriter: WriteJSON}
 }
 
 func (o *options) SuccessCode(code int) {
@@ -29,6 +80,106 @@ func (o *options) WriteResponse(w WriteResponse) {
 	o.responseWriter = w
 }
 
+func (o *options) Use(middlewares ...func(http.Handler) http.Handler) {
+	o.middlewares = append(o.middlewares, middlewares...)
+}
+
+func (o *options) BeforeHandle(hook func(*http.Request) error) {
+	o.beforeHandle = append(o.beforeHandle, hook)
+}
+
+func (o *options) AfterHandle(hook func(*http.Request, any, error)) {
+	o.afterHandle = append(o.afterHandle, hook)
+}
+
+func (o *options) OnPanic(hook func(*http.Request, *RecoveredError)) {
+	o.onPanic = append(o.onPanic, hook)
+}
+
+func (o *options) Timeout(d time.Duration) {
+	o.timeout = d
+}
+
+func (o *options) Idempotent(config IdempotencyConfig) {
+	o.idempotency = &config
+}
+
+func (o *options) Coalesce(key func(*http.Request) string) {
+	o.coalesceKey = key
+}
+
+func (o *options) Cache(config CacheConfig) {
+	o.cache = &config
+}
+
+func (o *options) ETag(current func(*http.Request) (any, error)) {
+	o.etag = &etagConfig{current: current}
+}
+
+func (o *options) Compress(config CompressConfig) {
+	o.compress = &config
+}
+
+func (o *options) MaxInFlight(config LimiterConfig) {
+	o.limiter = &config
+}
+
+func (o *options) RateLimit(config RateLimitConfig) {
+	o.rateLimit = &config
+}
+
+func (o *options) Breaker(config BreakerConfig) {
+	o.breaker = &config
+}
+
+func (o *options) Container(c *Container) {
+	o.container = c
+}
+
+func (o *options) UnitOfWork(uow UnitOfWork) {
+	o.unitOfWork = uow
+}
+
+func (o *options) Deprecation(config DeprecationConfig) {
+	o.deprecation = &config
+}
+
+func (o *options) CORS(config CORSConfig) {
+	o.cors = &config
+}
+
+func (o *options) Authenticate(authenticate func(*http.Request) (any, error)) {
+	o.authenticate = authenticate
+}
+
+func (o *options) Authorize(policy Policy) {
+	if o.policy != nil {
+		policy = And(*o.policy, policy)
+	}
+
+	o.policy = &policy
+}
+
+func (o *options) OnAuthorize(hook func(*http.Request, AuthorizationDecision)) {
+	o.onAuthorize = append(o.onAuthorize, hook)
+}
+
+func (o *options) CSRF(config CSRFConfig) {
+	o.csrf = &config
+}
+
+func (o *options) SecureHeaders(config SecureHeadersConfig) {
+	if o.secureHeaders != nil {
+		config = config.merge(*o.secureHeaders)
+	}
+
+	o.secureHeaders = &config
+}
+
+func (o *options) VerifySignature(scheme SignatureScheme) {
+	o.signature = &scheme
+}
+
 // Sets success response HTTP Status Code.
 func SuccessCode(code int) func(Options) {
 	return func(o Options) { o.SuccessCode(code) }
@@ -72,3 +223,26 @@ func ErrorHandle(matcher ErrorMatcher) func(Options) {
 func ResponseWriter(w WriteResponse) func(Options) {
 	return func(o Options) { o.WriteResponse(w) }
 }
+
+// Use wraps handler with middlewares.
+// First middleware is the outermost one.
+func Use(middlewares ...func(http.Handler) http.Handler) func(Options) {
+	return func(o Options) { o.Use(middlewares...) }
+}
+
+// BeforeHandle is called before handler in order of registration.
+// If hook returns error handler is not called and error is written as response.
+func BeforeHandle(hook func(*http.Request) error) func(Options) {
+	return func(o Options) { o.BeforeHandle(hook) }
+}
+
+// AfterHandle is called with handler result and error before response is written.
+// It is also called if BeforeHandle hook returned error.
+func AfterHandle(hook func(r *http.Request, result any, err error)) func(Options) {
+	return func(o Options) { o.AfterHandle(hook) }
+}
+
+// OnPanic is called if handler panicked.
+func OnPanic(hook func(*http.Request, *RecoveredError)) func(Options) {
+	return func(o Options) { o.OnPanic(hook) }
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// If client exceeded its rate limit - RateLimitError is returned.
+type RateLimitError struct {
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (err *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit of %d requests exceeded, retry after %s", err.Limit, err.RetryAfter)
+}
+
+// RateLimitResult is the state of token bucket after request was counted.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is the time until bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until next token is available if request was not allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps token buckets of rate limited clients.
+type RateLimitStore interface {
+	// Take takes single token from bucket of key refilled with rate tokens per second up to burst.
+	Take(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error)
+}
+
+// RateLimitConfig configures RateLimit option.
+type RateLimitConfig struct {
+	// Key identifies client, requests with empty key are not limited.
+	Key func(*http.Request) string
+	// Rate is number of requests per second.
+	Rate float64
+	// Burst is maximal number of requests made at once.
+	Burst int
+	// Store keeps token buckets.
+	// New MemoryRateLimitStore is used if nil.
+	Store RateLimitStore
+}
+
+// RateLimit limits clients identified by key to rate requests per second with burst.
+// Every response gets RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// requests over the limit get *RateLimitError (429 Too Many Requests unless matched otherwise).
+func RateLimit(key func(*http.Request) string, rate float64, burst int) func(Options) {
+	return RateLimitWith(RateLimitConfig{Key: key, Rate: rate, Burst: burst})
+}
+
+// RateLimitWith is RateLimit with full configuration.
+func RateLimitWith(config RateLimitConfig) func(Options) {
+	return func(o Options) { o.RateLimit(config) }
+}
+
+// RateLimitByIP is RateLimit key function that uses client IP address from request RemoteAddr.
+func RateLimitByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func (opts *options) rateLimitHandler(next http.Handler) http.Handler {
+	if opts.rateLimit == nil || opts.rateLimit.Key == nil {
+		return next
+	}
+
+	config := *opts.rateLimit
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := config.Key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := config.Store.Take(r.Context(), key, config.Rate, config.Burst)
+		if err != nil {
+			logger().Error("failed to take rate limit token, request is allowed", "error", err)
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(config.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			metrics().Add("controller_requests_rejected_total", 1, "route", r.Pattern, "reason", "rate_limit")
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+
+			opts.writeError(w, r, &RateLimitError{Limit: config.Burst, RetryAfter: result.RetryAfter})
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+const rateLimitShards = 64
+
+// MemoryRateLimitStore is in-memory RateLimitStore sharded by key.
+// Buckets that were idle long enough to refill completely are evicted.
+type MemoryRateLimitStore struct {
+	seed   maphash.Seed
+	shards [rateLimitShards]rateLimitShard
+}
+
+type rateLimitShard struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// full is the moment bucket is refilled completely.
+	full time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{seed: maphash.MakeSeed()}
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]*tokenBucket)
+		s.shards[i].lastSweep = time.Now()
+	}
+
+	return s
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rate float64, burst int) (RateLimitResult, error) {
+	shard := &s.shards[maphash.String(s.seed, key)%rateLimitShards]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := time.Now()
+	shard.sweep(now)
+
+	b, ok := shard.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		shard.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{Allowed: b.tokens >= 1}
+	if result.Allowed {
+		b.tokens--
+	} else {
+		result.RetryAfter = refillDuration(1-b.tokens, rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = refillDuration(float64(burst)-b.tokens, rate)
+	b.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// Len returns number of tracked buckets.
+func (s *MemoryRateLimitStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].buckets)
+		s.shards[i].mu.Unlock()
+	}
+
+	return n
+}
+
+// sweep evicts full buckets at most once per minute, must be called with shard.mu held.
+func (shard *rateLimitShard) sweep(now time.Time) {
+	if now.Sub(shard.lastSweep) < time.Minute {
+		return
+	}
+
+	for key, b := range shard.buckets {
+		if !now.Before(b.full) {
+			delete(shard.buckets, key)
+		}
+	}
+
+	shard.lastSweep = now
+}
+
+// refillDuration returns the time needed to refill tokens, buckets that are never refilled report 0.
+func refillDuration(tokens, rate float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,89 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimit", func() {
+	h := func(r *http.Request) (string, error) {
+		return "success", nil
+	}
+
+	get := func(action http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("limits requests per client", func() {
+		action := controller.Respond[string](h).With(controller.RateLimit(controller.RateLimitByIP, 1, 2))
+
+		first := get(action, "10.0.0.1:1234")
+
+		Expect(first.Code).To(Equal(http.StatusOK))
+		Expect(first.Header().Get("RateLimit-Limit")).To(Equal("2"))
+		Expect(first.Header().Get("RateLimit-Remaining")).To(Equal("1"))
+		Expect(first.Header().Get("RateLimit-Reset")).To(Equal("1"))
+
+		Expect(get(action, "10.0.0.1:1235").Code).To(Equal(http.StatusOK))
+
+		w := get(action, "10.0.0.1:1236")
+
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(w.Header().Get("RateLimit-Remaining")).To(Equal("0"))
+		Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+
+		Expect(get(action, "10.0.0.2:1234").Code).To(Equal(http.StatusOK))
+	})
+
+	It("with custom RateLimitError response", func() {
+		action := controller.Respond[string](h).
+			With(
+				controller.RateLimit(controller.RateLimitByIP, 1, 1),
+				controller.ErrorHandle(controller.MatchError(func(err error) (any, int) {
+					if _, ok := err.(*controller.RateLimitError); ok {
+						return &testError{Detail: "slow down"}, http.StatusTooManyRequests
+					}
+
+					return nil, 0
+				})),
+			)
+
+		get(action, "10.0.0.1:1234")
+		w := get(action, "10.0.0.1:1234")
+
+		var result testError
+
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(json.Unmarshal(w.Body.Bytes(), &result)).To(Succeed())
+		Expect(result.Detail).To(Equal("slow down"))
+	})
+
+	It("refills tokens over time", func() {
+		store := controller.NewMemoryRateLimitStore()
+		ctx := context.Background()
+
+		result, err := store.Take(ctx, "client", 1000, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Allowed).To(BeTrue())
+
+		Eventually(func() bool {
+			result, _ := store.Take(ctx, "client", 1000, 1)
+			return result.Allowed
+		}).Should(BeTrue())
+		Expect(store.Len()).To(Equal(1))
+	})
+})
@@ -1,14 +1,42 @@
 package controller
 
 import (
+	"compress/gzip"
+	"compress/zlib"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// ErrRequestBodyTooLarge is returned by readers if decompressed request body exceeds limit.
+var ErrRequestBodyTooLarge = errors.New("decompressed request body is too large")
+
+// SetMaxDecompressedBodySize limits size of request body decompressed by readers.
+// Default limit is 10 MiB.
+func SetMaxDecompressedBodySize(n int64) {
+	if n > 0 {
+		maxDecompressedBodySize.Store(n)
+	}
+}
+
+func init() {
+	maxDecompressedBodySize.Store(10 << 20)
+}
+
+var maxDecompressedBodySize atomic.Int64
+
 // Request reader to read JSON from Body.
 func ReadJSON[T any](req *http.Request) (*T, error) {
-	b, err := io.ReadAll(req.Body)
+	if span, ok := spanFromContext(req.Context()); ok {
+		defer func(start time.Time) { span.AddEvent("read", "duration", time.Since(start)) }(time.Now())
+	}
+
+	b, err := readBody(req)
 	if err != nil {
 		return nil, &ReadRequestError{err: err}
 	}
@@ -20,3 +48,44 @@ func ReadJSON[T any](req *http.Request) (*T, error) {
 
 	return &model, nil
 }
+
+// readBody reads request body decompressing it according to Content-Encoding header.
+func readBody(req *http.Request) ([]byte, error) {
+	var body io.ReadCloser
+
+	switch encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return io.ReadAll(req.Body)
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		body = zr
+	case "deflate":
+		zr, err := zlib.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		body = zr
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", encoding)
+	}
+
+	defer body.Close()
+
+	limit := maxDecompressedBodySize.Load()
+
+	b, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > limit {
+		return nil, ErrRequestBodyTooLarge
+	}
+
+	return b, nil
+}
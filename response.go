@@ -3,7 +3,6 @@ package controller
 import (
 	"encoding/json"
 	"net/http"
-	"runtime/debug"
 )
 
 type DecoratedResponse[T, U any] func(U) Respond[T]
@@ -20,46 +19,24 @@ type Respond[T any] func(*http.Request) (T, error)
 
 // With allows change default Respond behaviour with options.
 func (handle Respond[T]) With(opts ...func(Options)) http.Handler {
-	options := options{successCode: http.StatusOK, responseWriter: WriteJSON}
+	options := newOptions()
 	for _, option := range opts {
-		option(&options)
+		option(options)
 	}
 
-	return handle.getHttpHandle(&options)
+	return handle.getHttpHandle(options)
 }
 
 func (handle Respond[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handle.
-		getHttpHandle(&options{successCode: http.StatusOK, responseWriter: WriteJSON}).
-		ServeHTTP(w, r)
+	handle.getHttpHandle(newOptions()).ServeHTTP(w, r)
 }
 
-func (handle Respond[T]) getHttpHandle(opts *options) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rp := recover(); rp != nil {
-				stack := debug.Stack()
-				err := newRecoveredError(rp, stack)
-
-				logger().Error("request failed: recovered from panic during request", "error", err, "stack", string(stack))
-
-				response, code := getErrorResponse(r, err, opts.errorHandlers)
-				opts.responseWriter.WriteError(r, w, response, code)
-			}
-		}()
-
-		result, err := handle(r)
-		if err != nil {
-			logger().Error("request failed", "error", err)
-
-			response, code := getErrorResponse(r, err, opts.errorHandlers)
-			opts.responseWriter.WriteError(r, w, response, code)
-
-			return
-		}
+func (handle Respond[T]) getHttpHandle(opts *options) http.Handler {
+	return opts.handler(handle.getHandleFunc(opts))
+}
 
-		opts.responseWriter.Write(r, w, result, opts.successCode)
-	}
+func (handle Respond[T]) getHandleFunc(opts *options) handleFunc {
+	return opts.authorized(func(r *http.Request) (any, error) { return handle(r) })
 }
 
 type WriteResponse interface {
@@ -79,9 +56,10 @@ func (fn WriteResponseFn) WriteError(r *http.Request, w http.ResponseWriter, err
 }
 
 // Response writer to write JSON response
-// in body with Content-Type "application/json; charset=utf-8" Header.
+// in body with Content-Type "application/json; charset=utf-8" and X-Content-Type-Options "nosniff" Headers.
 var WriteJSON WriteResponseFn = func(_ *http.Request, w http.ResponseWriter, data any, status int) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(status)
 
 	if data == nil {
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Endpoint is handler Router can build with its options: Respond, Handle or Inject.
+type Endpoint interface {
+	getHttpHandle(*options) http.Handler
+	getHandleFunc(*options) handleFunc
+}
+
+// Route is Router route table entry.
+type Route struct {
+	// Pattern is ServeMux pattern route was registered with.
+	Pattern string
+	// Method is empty if route matches any method.
+	Method string
+	Path   string
+	// Deprecation is set if endpoint was registered with Deprecated option.
+	Deprecation *DeprecationConfig
+	// Policy is name of Authorize policy endpoint was registered with.
+	Policy string
+}
+
+// Router is http.Handler that wraps http.ServeMux,
+// builds registered endpoints with shared options and keeps route table.
+// Requests with method not registered for matched path
+// are responded with *MethodNotAllowedError and Allow header.
+type Router struct {
+	mux     *http.ServeMux
+	options *options
+	table   *routeTable
+
+	prefix string
+	// levels are options of router and its parent groups, from the outermost one
+	levels [][]func(Options)
+}
+
+type routeTable struct {
+	mu        sync.RWMutex
+	routes    []Route
+	preflight map[string]bool
+}
+
+// NewRouter returns Router which applies opts to every registered endpoint
+// and uses them to write its own errors.
+func NewRouter(opts ...func(Options)) *Router {
+	options := newOptions()
+	for _, option := range opts {
+		option(options)
+	}
+
+	return &Router{
+		mux:     http.NewServeMux(),
+		options: options,
+		table:   &routeTable{},
+		levels:  [][]func(Options){opts},
+	}
+}
+
+// Group returns Router that registers routes under prefix on the same ServeMux.
+// Endpoints of group are built with options of router, then group opts, then their own options,
+// error matchers are evaluated in reverse order: endpoint ones first, then group ones, then router ones.
+// Groups can be nested.
+func (router *Router) Group(prefix string, opts ...func(Options)) *Router {
+	group := *router
+	group.prefix = router.prefix + strings.TrimSuffix(prefix, "/")
+	group.levels = append(append([][]func(Options){}, router.levels...), opts)
+
+	return &group
+}
+
+// Register builds endpoint with Router options followed by opts
+// and registers it for ServeMux pattern, e.g. "GET /users/{id}".
+func (router *Router) Register(pattern string, endpoint Endpoint, opts ...func(Options)) {
+	options := newOptions()
+
+	var matchers []ErrorMatcher
+	for _, level := range append(router.levels, opts) {
+		n := len(options.errorHandlers)
+		for _, option := range level {
+			option(options)
+		}
+
+		matchers = append(append([]ErrorMatcher{}, options.errorHandlers[n:]...), matchers...)
+	}
+
+	options.errorHandlers = matchers
+
+	router.handle(pattern, endpoint.getHttpHandle(options), options)
+}
+
+// Handle registers handler for ServeMux pattern under Router prefix as is.
+func (router *Router) Handle(pattern string, handler http.Handler) {
+	router.handle(pattern, handler, nil)
+}
+
+func (router *Router) handle(pattern string, handler http.Handler, opts *options) {
+	route := Route{Path: pattern}
+	if opts != nil {
+		route.Deprecation = opts.deprecation
+
+		if opts.policy != nil {
+			route.Policy = opts.policy.name
+		}
+	}
+
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		route.Method, route.Path = method, strings.TrimSpace(path)
+	}
+
+	if i := strings.Index(route.Path, "/"); i >= 0 {
+		route.Path = route.Path[:i] + router.prefix + route.Path[i:]
+	}
+
+	route.Pattern = route.Path
+	if route.Method != "" {
+		route.Pattern = route.Method + " " + route.Path
+	}
+
+	router.mux.Handle(route.Pattern, handler)
+
+	router.table.mu.Lock()
+	defer router.table.mu.Unlock()
+
+	router.table.routes = append(router.table.routes, route)
+
+	// preflight requests are answered by OPTIONS route registered once per path
+	if opts != nil && opts.cors != nil && route.Method != "" && route.Method != http.MethodOptions {
+		if router.table.preflight == nil {
+			router.table.preflight = make(map[string]bool)
+		}
+
+		if !router.table.preflight[route.Path] {
+			router.table.preflight[route.Path] = true
+			router.mux.Handle(http.MethodOptions+" "+route.Path, opts.preflightHandler())
+		}
+	}
+}
+
+// Routes returns all routes registered on ServeMux in order of registration.
+func (router *Router) Routes() []Route {
+	router.table.mu.RLock()
+	defer router.table.mu.RUnlock()
+
+	return append([]Route(nil), router.table.routes...)
+}
+
+// ServeHTTP serves all routes registered on ServeMux, including ones of other groups.
+func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, pattern := router.mux.Handler(r)
+	if pattern != "" {
+		router.mux.ServeHTTP(w, r)
+		return
+	}
+
+	// ServeMux responds with plain text to requests it has no route for,
+	// 405 Method Not Allowed is rewritten in Router error format
+	buf := newResponseBuffer(make(http.Header))
+	h.ServeHTTP(buf, r)
+
+	if buf.status() != http.StatusMethodNotAllowed {
+		buf.flush(w)
+		return
+	}
+
+	allow := buf.Header().Get("Allow")
+	w.Header().Set("Allow", allow)
+	router.options.setSecureHeaders(w.Header())
+
+	router.options.writeError(w, r, &MethodNotAllowedError{Method: r.Method, Allow: strings.Split(allow, ", ")})
+}
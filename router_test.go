@@ -0,0 +1,151 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testUserInput struct {
+	ID   int    `path:"id"`
+	Name string `json:"name"`
+}
+
+var _ = Describe("Router", func() {
+	var router *controller.Router
+
+	BeforeEach(func() {
+		router = controller.NewRouter(controller.ErrorWithCode[*testError](http.StatusBadRequest))
+		router.Register("GET /users/{id}", controller.Handle[testUserInput, int](
+			func(r *http.Request, in testUserInput) (int, error) {
+				return in.ID, nil
+			},
+		))
+		router.Register("PUT /users/{id}", controller.Handle[testUserInput, testUserInput](
+			func(r *http.Request, in testUserInput) (testUserInput, error) {
+				return in, nil
+			},
+		), controller.SuccessCode(http.StatusAccepted))
+		router.Register("GET /health", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "", &testError{Detail: "unhealthy"}
+		}))
+	})
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		return w
+	}
+
+	It("binds path values into input", func() {
+		w := serve(http.MethodGet, "/users/42", "")
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("42\n"))
+
+		w = serve(http.MethodPut, "/users/7", `{"name":"bob","ID":1}`)
+
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		Expect(w.Body.String()).To(Equal("{\"ID\":7,\"name\":\"bob\"}\n"))
+	})
+
+	It("responds with bad request to malformed path value", func() {
+		w := serve(http.MethodGet, "/users/abc", "")
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Body.String()).To(ContainSubstring(`path value \"id\"`))
+	})
+
+	It("applies router options to endpoints", func() {
+		Expect(serve(http.MethodGet, "/health", "").Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("responds with method not allowed in error format", func() {
+		w := serve(http.MethodDelete, "/users/42", "")
+
+		Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(w.Header().Get("Allow")).To(Equal("GET, HEAD, PUT"))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json; charset=utf-8"))
+		Expect(w.Body.String()).To(Equal("\"method DELETE is not allowed, allowed methods: GET, HEAD, PUT\"\n"))
+	})
+
+	It("responds with not found to unknown path", func() {
+		Expect(serve(http.MethodGet, "/unknown", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("keeps route table", func() {
+		Expect(router.Routes()).To(Equal([]controller.Route{
+			{Pattern: "GET /users/{id}", Method: http.MethodGet, Path: "/users/{id}"},
+			{Pattern: "PUT /users/{id}", Method: http.MethodPut, Path: "/users/{id}"},
+			{Pattern: "GET /health", Method: http.MethodGet, Path: "/health"},
+		}))
+	})
+
+	Describe("Group", func() {
+		var calls []string
+
+		middleware := func(name string) func(http.Handler) http.Handler {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+
+		failing := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "", &testError{Detail: "failed"}
+		})
+
+		BeforeEach(func() {
+			calls = nil
+			router = controller.NewRouter(
+				controller.ErrorWithCode[*testError](http.StatusBadRequest),
+				controller.Use(middleware("router")),
+			)
+
+			v1 := router.Group("/v1/",
+				controller.ErrorWithCode[*testError](http.StatusConflict),
+				controller.Use(middleware("group")),
+			)
+			v1.Register("GET /orders", failing)
+			v1.Register("GET /items", failing,
+				controller.ErrorWithCode[*testError](http.StatusUnprocessableEntity),
+				controller.Use(middleware("endpoint")),
+			)
+
+			posts := v1.Group("/users/{id}")
+			posts.Register("GET /posts", controller.Handle[testUserInput, int](
+				func(r *http.Request, in testUserInput) (int, error) {
+					return in.ID, nil
+				},
+			))
+		})
+
+		It("registers routes under group prefix", func() {
+			Expect(serve(http.MethodGet, "/v1/users/3/posts", "").Body.String()).To(Equal("3\n"))
+			Expect(router.Routes()).To(ContainElement(controller.Route{
+				Pattern: "GET /v1/users/{id}/posts",
+				Method:  http.MethodGet,
+				Path:    "/v1/users/{id}/posts",
+			}))
+		})
+
+		It("evaluates error matchers from the innermost level", func() {
+			Expect(serve(http.MethodGet, "/v1/orders", "").Code).To(Equal(http.StatusConflict))
+			Expect(serve(http.MethodGet, "/v1/items", "").Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("applies middlewares from the outermost level", func() {
+			serve(http.MethodGet, "/v1/items", "")
+
+			Expect(calls).To(Equal([]string{"router", "group", "endpoint"}))
+		})
+	})
+})
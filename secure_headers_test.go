@@ -0,0 +1,74 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecureHeaders", func() {
+	var router *controller.Router
+
+	BeforeEach(func() {
+		router = controller.NewRouter(
+			controller.SecureHeaders(controller.SecureHeadersConfig{}),
+			controller.ErrorWithCode[*testError](http.StatusBadRequest),
+		)
+		router.Register("GET /ok", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "ok", nil
+		}))
+		router.Register("GET /fail", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "", &testError{Detail: "failed"}
+		}))
+		router.Register("GET /docs", controller.Respond[string](func(r *http.Request) (string, error) {
+			return "docs", nil
+		}), controller.SecureHeaders(controller.SecureHeadersConfig{
+			ContentSecurityPolicy: "default-src 'self'",
+			FrameOptions:          "-",
+		}))
+	})
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+
+		return w
+	}
+
+	It("adds default headers to success and error responses", func() {
+		for _, w := range []*httptest.ResponseRecorder{
+			serve(http.MethodGet, "/ok"),
+			serve(http.MethodGet, "/fail"),
+			serve(http.MethodPost, "/ok"),
+		} {
+			Expect(w.Header().Get("Strict-Transport-Security")).To(Equal("max-age=63072000; includeSubDomains"))
+			Expect(w.Header().Get("X-Content-Type-Options")).To(Equal("nosniff"))
+			Expect(w.Header().Get("Content-Security-Policy")).To(Equal("default-src 'none'; frame-ancestors 'none'"))
+			Expect(w.Header().Get("Referrer-Policy")).To(Equal("no-referrer"))
+			Expect(w.Header().Get("X-Frame-Options")).To(Equal("DENY"))
+			Expect(w.Header().Get("Cross-Origin-Resource-Policy")).To(Equal("same-origin"))
+		}
+	})
+
+	It("lets handler override headers", func() {
+		w := serve(http.MethodGet, "/docs")
+
+		Expect(w.Header().Get("Content-Security-Policy")).To(Equal("default-src 'self'"))
+		Expect(w.Header().Values("X-Frame-Options")).To(BeEmpty())
+		Expect(w.Header().Get("Referrer-Policy")).To(Equal("no-referrer"))
+	})
+
+	It("sends nosniff with JSON responses by default", func() {
+		w := httptest.NewRecorder()
+		controller.Respond[string](func(r *http.Request) (string, error) {
+			return "ok", nil
+		}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Header().Get("X-Content-Type-Options")).To(Equal("nosniff"))
+		Expect(w.Header().Get("Content-Security-Policy")).To(BeEmpty())
+	})
+})
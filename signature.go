@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// If request signature is missing or not valid - SignatureError is returned.
+type SignatureError struct {
+	Reason string
+}
+
+func (err *SignatureError) Error() string {
+	return "signature verification failed: " + err.Reason
+}
+
+// SignatureEncoding is encoding of signature in header.
+type SignatureEncoding int
+
+const (
+	SignatureHex SignatureEncoding = iota
+	SignatureBase64
+)
+
+// SignatureVerifier reports whether signature of payload is valid.
+type SignatureVerifier func(payload, signature []byte) bool
+
+// HMACSHA256 verifies HMAC-SHA256 signatures made with secret.
+func HMACSHA256(secret []byte) SignatureVerifier {
+	return func(payload, signature []byte) bool {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(payload)
+
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+}
+
+// Ed25519 verifies Ed25519 signatures made with private key of publicKey.
+func Ed25519(publicKey ed25519.PublicKey) SignatureVerifier {
+	return func(payload, signature []byte) bool {
+		return ed25519.Verify(publicKey, payload, signature)
+	}
+}
+
+// SignatureScheme describes how request is signed, e.g.:
+//
+//	// "X-Hub-Signature-256: sha256=<hex>"
+//	SignatureScheme{Header: "X-Hub-Signature-256", Prefix: "sha256=", Verify: HMACSHA256(secret)}
+//	// "Signature: t=<unix>,v1=<hex>" of "<unix>.<body>"
+//	SignatureScheme{
+//		Header: "Signature", TimestampKey: "t", SignatureKey: "v1",
+//		Tolerance: 5 * time.Minute, Verify: HMACSHA256(secret),
+//	}
+type SignatureScheme struct {
+	// Header is request header signature is read from.
+	Header string
+	// Prefix is trimmed from Header value.
+	Prefix string
+	// SignatureKey is key of signature in Header value of "key=value,..." format,
+	// whole Header value is signature if empty.
+	// Header value may contain several signatures, e.g. during secret rotation.
+	SignatureKey string
+	// TimestampKey is key of unix timestamp in Header value of "key=value,..." format.
+	TimestampKey string
+	// TimestampHeader is request header unix timestamp is read from.
+	TimestampHeader string
+	// Encoding is encoding of signature, SignatureHex by default.
+	Encoding SignatureEncoding
+	// Payload returns signed payload from timestamp and body.
+	// If nil "<timestamp>.<body>" is signed when Tolerance is set and body is signed otherwise,
+	// so that timestamp can not be replaced to replay captured request.
+	Payload func(timestamp string, body []byte) []byte
+	// Tolerance is replay window, requests with timestamp further from now are rejected.
+	// Timestamp is not required if 0.
+	Tolerance time.Duration
+	// MaxBodySize is maximal size of request body in bytes.
+	// 1 MiB is used if 0.
+	MaxBodySize int64
+	// Verify verifies signature, required.
+	Verify SignatureVerifier
+}
+
+// VerifySignature reads request body and verifies its signature before handler is called.
+// Body is available to ReadJSON and other readers afterwards.
+// Failures result in *SignatureError (401 Unauthorized unless matched otherwise).
+func VerifySignature(scheme SignatureScheme) func(Options) {
+	return func(o Options) { o.VerifySignature(scheme) }
+}
+
+func (opts *options) signatureHandler(next http.Handler) http.Handler {
+	if opts.signature == nil {
+		return next
+	}
+
+	scheme := *opts.signature
+	if scheme.MaxBodySize <= 0 {
+		scheme.MaxBodySize = 1 << 20
+	}
+
+	if scheme.Payload == nil && scheme.Tolerance > 0 {
+		scheme.Payload = func(timestamp string, body []byte) []byte {
+			return append([]byte(timestamp+"."), body...)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, scheme.MaxBodySize))
+		if err != nil {
+			opts.writeError(w, r, &ReadRequestError{err: err})
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err := scheme.verify(r, body, time.Now()); err != nil {
+			opts.writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (scheme SignatureScheme) verify(r *http.Request, body []byte, now time.Time) error {
+	value := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(scheme.Header)), scheme.Prefix)
+	if value == "" {
+		return &SignatureError{Reason: scheme.Header + " header is missing"}
+	}
+
+	var timestamp string
+	if scheme.TimestampHeader != "" {
+		timestamp = r.Header.Get(scheme.TimestampHeader)
+	}
+
+	encoded := []string{value}
+	if scheme.SignatureKey != "" {
+		encoded = nil
+
+		for _, field := range strings.Split(value, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch key {
+			case scheme.SignatureKey:
+				encoded = append(encoded, value)
+			case scheme.TimestampKey:
+				timestamp = value
+			}
+		}
+	}
+
+	if scheme.Tolerance > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return &SignatureError{Reason: "timestamp is missing or malformed"}
+		}
+
+		if age := now.Sub(time.Unix(unix, 0)); age > scheme.Tolerance || age < -scheme.Tolerance {
+			return &SignatureError{Reason: "timestamp is outside of tolerance"}
+		}
+	}
+
+	payload := body
+	if scheme.Payload != nil {
+		payload = scheme.Payload(timestamp, body)
+	}
+
+	for _, e := range encoded {
+		signature, err := scheme.decode(e)
+		if err == nil && scheme.Verify(payload, signature) {
+			return nil
+		}
+	}
+
+	return &SignatureError{Reason: "signature is not valid"}
+}
+
+func (scheme SignatureScheme) decode(signature string) ([]byte, error) {
+	if scheme.Encoding == SignatureBase64 {
+		return base64.StdEncoding.DecodeString(signature)
+	}
+
+	return hex.DecodeString(signature)
+}
@@ -0,0 +1,159 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testEvent struct {
+	Type string `json:"type"`
+}
+
+var _ = Describe("VerifySignature", func() {
+	secret := []byte("webhook-secret")
+	body := `{"type":"payment.succeeded"}`
+
+	h := controller.Respond[string](func(r *http.Request) (string, error) {
+		event, err := controller.ReadJSON[testEvent](r)
+		if err != nil {
+			return "", err
+		}
+
+		return event.Type, nil
+	})
+
+	hmacHex := func(payload string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(payload))
+
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	serve := func(action http.Handler, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		for name, values := range header {
+			r.Header[name] = values
+		}
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("verifies HMAC-SHA256 signature and keeps body readable", func() {
+		action := h.With(controller.VerifySignature(controller.SignatureScheme{
+			Header: "X-Hub-Signature-256",
+			Prefix: "sha256=",
+			Verify: controller.HMACSHA256(secret),
+		}))
+
+		w := serve(action, http.Header{"X-Hub-Signature-256": {"sha256=" + hmacHex(body)}})
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("\"payment.succeeded\"\n"))
+
+		w = serve(action, http.Header{"X-Hub-Signature-256": {"sha256=" + hmacHex("other")}})
+
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Body.String()).To(Equal("\"signature verification failed: signature is not valid\"\n"))
+
+		Expect(serve(action, nil).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	Describe("timestamped scheme", func() {
+		action := h.With(controller.VerifySignature(controller.SignatureScheme{
+			Header:       "Signature",
+			SignatureKey: "v1",
+			TimestampKey: "t",
+			Payload: func(timestamp string, body []byte) []byte {
+				return []byte(timestamp + "." + string(body))
+			},
+			Tolerance: 5 * time.Minute,
+			Verify:    controller.HMACSHA256(secret),
+		}))
+
+		signature := func(at time.Time) string {
+			timestamp := strconv.FormatInt(at.Unix(), 10)
+			return "t=" + timestamp + ",v1=" + hmacHex("rotated") + ",v1=" + hmacHex(timestamp+"."+body)
+		}
+
+		It("accepts signature within replay window", func() {
+			Expect(serve(action, http.Header{"Signature": {signature(time.Now())}}).Code).To(Equal(http.StatusOK))
+		})
+
+		It("signs timestamp by default", func() {
+			action := h.With(controller.VerifySignature(controller.SignatureScheme{
+				Header:          "X-Signature",
+				TimestampHeader: "X-Ts",
+				Tolerance:       5 * time.Minute,
+				Verify:          controller.HMACSHA256(secret),
+			}))
+
+			timestamp := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+			signature := hmacHex(timestamp + "." + body)
+
+			Expect(serve(action, http.Header{"X-Signature": {signature}, "X-Ts": {timestamp}}).Code).
+				To(Equal(http.StatusOK))
+
+			replayed := strconv.FormatInt(time.Now().Unix(), 10)
+			w := serve(action, http.Header{"X-Signature": {signature}, "X-Ts": {replayed}})
+
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+			Expect(w.Body.String()).To(ContainSubstring("signature is not valid"))
+		})
+
+		It("rejects signature outside of replay window", func() {
+			w := serve(action, http.Header{"Signature": {signature(time.Now().Add(-time.Hour))}})
+
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+			Expect(w.Body.String()).To(ContainSubstring("timestamp is outside of tolerance"))
+		})
+	})
+
+	It("verifies Ed25519 signature", func() {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		action := h.With(controller.VerifySignature(controller.SignatureScheme{
+			Header:          "X-Signature-Ed25519",
+			TimestampHeader: "X-Signature-Timestamp",
+			Encoding:        controller.SignatureBase64,
+			Payload: func(timestamp string, body []byte) []byte {
+				return append([]byte(timestamp), body...)
+			},
+			Verify: controller.Ed25519(publicKey),
+		}))
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		signature := ed25519.Sign(privateKey, []byte(timestamp+body))
+
+		w := serve(action, http.Header{
+			"X-Signature-Ed25519":   {base64.StdEncoding.EncodeToString(signature)},
+			"X-Signature-Timestamp": {timestamp},
+		})
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = serve(action, http.Header{
+			"X-Signature-Ed25519":   {base64.StdEncoding.EncodeToString(signature)},
+			"X-Signature-Timestamp": {"0"},
+		})
+
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+	})
+})
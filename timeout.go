@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout cancels handler context after d.
+// If handler did not finish in time *TimeoutError is written as response
+// (503 Service Unavailable unless matched otherwise),
+// anything handler writes afterwards is discarded.
+func Timeout(d time.Duration) func(Options) {
+	return func(o Options) { o.Timeout(d) }
+}
+
+func (opts *options) timeoutHandler(next http.Handler) http.Handler {
+	d := opts.timeout
+	if d <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		r = r.WithContext(ctx)
+		slot, _ := r.Context().Value(lateResponseKey{}).(*lateResponseSlot)
+		tw := &timeoutWriter{buf: newResponseBuffer(w.Header().Clone()), keepLate: slot != nil}
+		late := &lateResponse{buf: tw.buf, finished: make(chan struct{})}
+		done := make(chan struct{})
+		panicChan := make(chan any, 1)
+
+		go func() {
+			defer close(late.finished)
+			defer func() {
+				if p := recover(); p != nil {
+					late.panicked = true
+					panicChan <- p
+				}
+			}()
+
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.buf.flush(w)
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+
+			if slot != nil {
+				slot.late = late
+			}
+
+			err := ctx.Err()
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = &TimeoutError{Timeout: d}
+				logger().Error("request failed", "error", err)
+			}
+
+			opts.writeError(w, r, err)
+		}
+	})
+}
+
+type lateResponseKey struct{}
+
+// lateResponseSlot is set in request context by stage that needs to know
+// about handler that kept running after timeout response was sent.
+type lateResponseSlot struct {
+	late *lateResponse
+}
+
+// lateResponse is response handler writes after timeout,
+// it is complete once finished is closed.
+type lateResponse struct {
+	buf      *responseBuffer
+	finished chan struct{}
+	panicked bool
+}
+
+// timeoutWriter buffers response so that handler
+// can not write anything after timeout response was sent.
+// If keepLate is set late response is kept in buffer, but never flushed.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	buf      *responseBuffer
+	timedOut bool
+	keepLate bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.buf.Header()
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.timedOut || tw.keepLate {
+		tw.buf.WriteHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut && !tw.keepLate {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	return tw.buf.Write(p)
+}
@@ -0,0 +1,95 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timeout", func() {
+	slow := func(r *http.Request) (string, error) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+
+		return "late", nil
+	}
+
+	It("with default timeout response", func() {
+		w := httptest.NewRecorder()
+		controller.Respond[string](slow).
+			With(controller.Timeout(10*time.Millisecond)).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+
+		var result string
+
+		Expect(json.Unmarshal(w.Body.Bytes(), &result)).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal("handler timed out after 10ms"))
+	})
+
+	It("with custom timeout response", func() {
+		w := httptest.NewRecorder()
+		controller.Respond[string](slow).
+			With(
+				controller.Timeout(10*time.Millisecond),
+				controller.ErrorWithCode[*controller.TimeoutError](http.StatusGatewayTimeout),
+			).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusGatewayTimeout))
+	})
+
+	It("with response written in time", func() {
+		h := func(r *http.Request) (string, error) {
+			return "success", nil
+		}
+
+		w := httptest.NewRecorder()
+		controller.Respond[string](h).
+			With(controller.Timeout(time.Second), controller.SuccessCode(http.StatusCreated)).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusCreated))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json; charset=utf-8"))
+
+		var result string
+
+		Expect(json.Unmarshal(w.Body.Bytes(), &result)).ShouldNot(HaveOccurred())
+		Expect(result).To(Equal("success"))
+	})
+
+	It("with GatewayTimeout if handler returned context.DeadlineExceeded", func() {
+		h := func(r *http.Request) (string, error) {
+			return "", context.DeadlineExceeded
+		}
+
+		w := httptest.NewRecorder()
+		controller.Respond[string](h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(w.Code).To(Equal(http.StatusGatewayTimeout))
+	})
+
+	It("with no body if client closed request", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		h := func(r *http.Request) (string, error) {
+			return "", r.Context().Err()
+		}
+
+		w := httptest.NewRecorder()
+		controller.Respond[string](h).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+		Expect(w.Code).To(Equal(controller.StatusClientClosedRequest))
+		Expect(w.Body.Bytes()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Tracer starts Span around handler execution.
+// parent is W3C Trace Context extracted from request headers
+// and is not valid if request did not carry one.
+type Tracer interface {
+	Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span)
+}
+
+// Span is a single traced handler execution.
+type Span interface {
+	// SpanContext returns W3C Trace Context of this Span.
+	SpanContext() SpanContext
+	// SetStatus records HTTP Status Code written in response.
+	SetStatus(code int)
+	// RecordError records error returned by handler.
+	RecordError(err error)
+	// RecordPanic records panic recovered during handler execution.
+	RecordPanic(err *RecoveredError)
+	// AddEvent records timed event with key-value attributes.
+	AddEvent(name string, attrs ...any)
+	// End finishes Span.
+	End()
+}
+
+// SetTracer sets Tracer used by all handlers.
+// Passing nil restores default no-op Tracer.
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = noopTracer{}
+	}
+
+	tracerPtr.Store(&t)
+}
+
+func init() {
+	SetTracer(nil)
+}
+
+var tracerPtr atomic.Pointer[Tracer]
+
+func tracer() Tracer {
+	return *tracerPtr.Load()
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns Span of current request.
+// If request is not traced no-op Span is returned.
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := spanFromContext(ctx); ok {
+		return span
+	}
+
+	return noopSpan{}
+}
+
+func spanFromContext(ctx context.Context) (Span, bool) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	return span, ok
+}
+
+func trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := tracer()
+		if _, ok := t.(noopTracer); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, span := t.Start(r.Context(), spanName(r), ExtractTraceContext(r.Header))
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() { span.SetStatus(sw.status()) }()
+
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(ctx, spanKey{}, span)))
+	})
+}
+
+// statusWriter records status code actually written in response,
+// including responses written by stages that never reach handler, e.g. cache hits.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.code == 0 && code >= http.StatusOK {
+		sw.code = code
+	}
+
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if sw.code == 0 {
+		sw.code = http.StatusOK
+	}
+
+	return sw.ResponseWriter.Write(p)
+}
+
+func (sw *statusWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+func (sw *statusWriter) status() int {
+	if sw.code == 0 {
+		return http.StatusOK
+	}
+
+	return sw.code
+}
+
+func spanName(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+
+	return r.Method + " " + r.URL.Path
+}
+
+const (
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+)
+
+// SpanContext is W3C Trace Context propagated in traceparent and tracestate headers.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+	TraceState string
+}
+
+// IsValid reports whether both TraceID and SpanID are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Sampled reports whether sampled flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.TraceFlags&0x01 == 0x01
+}
+
+// String returns SpanContext in traceparent header format.
+func (sc SpanContext) String() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, sc.TraceFlags)
+}
+
+// ExtractTraceContext reads traceparent and tracestate headers.
+// Returns invalid SpanContext if traceparent is missing or malformed.
+func ExtractTraceContext(h http.Header) SpanContext {
+	var sc SpanContext
+	if !parseTraceParent(h.Get(traceParentHeader), &sc) {
+		return SpanContext{}
+	}
+
+	sc.TraceState = strings.Join(h.Values(traceStateHeader), ",")
+
+	return sc
+}
+
+// InjectTraceContext writes sc to traceparent and tracestate headers.
+// Invalid SpanContext is ignored.
+func InjectTraceContext(h http.Header, sc SpanContext) {
+	if !sc.IsValid() {
+		return
+	}
+
+	h.Set(traceParentHeader, sc.String())
+
+	if sc.TraceState != "" {
+		h.Set(traceStateHeader, sc.TraceState)
+	}
+}
+
+func parseTraceParent(s string, sc *SpanContext) bool {
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return false
+	}
+
+	version := s[:2]
+	if !isLowerHex(version) || version == "ff" {
+		return false
+	}
+
+	// future versions may append fields, version 00 may not
+	if (version == "00" && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return false
+	}
+
+	if !isLowerHex(s[3:35]) || !isLowerHex(s[36:52]) || !isLowerHex(s[53:55]) {
+		return false
+	}
+
+	var flags [1]byte
+
+	_, _ = hex.Decode(sc.TraceID[:], []byte(s[3:35]))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(s[36:52]))
+	_, _ = hex.Decode(flags[:], []byte(s[53:55]))
+
+	sc.TraceFlags = flags[0]
+
+	return sc.IsValid()
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, parent SpanContext) (context.Context, Span) {
+	return ctx, noopSpan{parent: parent}
+}
+
+type noopSpan struct {
+	parent SpanContext
+}
+
+func (s noopSpan) SpanContext() SpanContext  { return s.parent }
+func (noopSpan) SetStatus(int)               {}
+func (noopSpan) RecordError(error)           {}
+func (noopSpan) RecordPanic(*RecoveredError) {}
+func (noopSpan) AddEvent(string, ...any)     {}
+func (noopSpan) End()                        {}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// RecordingTracer is in-memory Tracer intended for tests.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan is a snapshot of Span recorded by RecordingTracer.
+type RecordedSpan struct {
+	Name      string
+	Parent    SpanContext
+	Context   SpanContext
+	Status    int
+	Err       error
+	Panic     *RecoveredError
+	Events    []SpanEvent
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// SpanEvent is an event added to RecordedSpan.
+type SpanEvent struct {
+	Name  string
+	Time  time.Time
+	Attrs []any
+}
+
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span) {
+	sc := SpanContext{TraceFlags: 0x01}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.TraceFlags = parent.TraceFlags
+		sc.TraceState = parent.TraceState
+	} else {
+		_, _ = rand.Read(sc.TraceID[:])
+	}
+
+	_, _ = rand.Read(sc.SpanID[:])
+
+	span := &RecordedSpan{Name: name, Parent: parent, Context: sc, StartTime: time.Now()}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return ctx, &recordingSpan{tracer: t, span: span}
+}
+
+// Spans returns copies of all spans started so far.
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(t.spans))
+	for i, span := range t.spans {
+		spans[i] = *span
+		spans[i].Events = append([]SpanEvent(nil), span.Events...)
+	}
+
+	return spans
+}
+
+// Reset forgets all recorded spans.
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+type recordingSpan struct {
+	tracer *RecordingTracer
+	span   *RecordedSpan
+}
+
+func (s *recordingSpan) SpanContext() SpanContext {
+	return s.span.Context
+}
+
+func (s *recordingSpan) SetStatus(code int) {
+	s.update(func(span *RecordedSpan) { span.Status = code })
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.update(func(span *RecordedSpan) { span.Err = err })
+}
+
+func (s *recordingSpan) RecordPanic(err *RecoveredError) {
+	s.update(func(span *RecordedSpan) { span.Panic = err })
+}
+
+func (s *recordingSpan) AddEvent(name string, attrs ...any) {
+	event := SpanEvent{Name: name, Time: time.Now(), Attrs: attrs}
+	s.update(func(span *RecordedSpan) { span.Events = append(span.Events, event) })
+}
+
+func (s *recordingSpan) End() {
+	s.update(func(span *RecordedSpan) { span.EndTime = time.Now() })
+}
+
+func (s *recordingSpan) update(fn func(*RecordedSpan)) {
+	s.tracer.mu.Lock()
+	fn(s.span)
+	s.tracer.mu.Unlock()
+}
@@ -0,0 +1,186 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracer", func() {
+	var tracer *controller.RecordingTracer
+
+	BeforeEach(func() {
+		tracer = controller.NewRecordingTracer()
+		controller.SetTracer(tracer)
+	})
+
+	AfterEach(func() {
+		controller.SetTracer(nil)
+	})
+
+	eventNames := func(span controller.RecordedSpan) []string {
+		names := make([]string, 0, len(span.Events))
+		for _, event := range span.Events {
+			names = append(names, event.Name)
+		}
+
+		return names
+	}
+
+	It("records successful request", func() {
+		h := func(r *http.Request) (string, error) {
+			_, err := controller.ReadJSON[string](r)
+			return "success", err
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(`"Hello World"`))
+		w := httptest.NewRecorder()
+
+		controller.Respond[string](h).With(controller.SuccessCode(http.StatusCreated)).ServeHTTP(w, r)
+
+		Expect(w.Code).To(Equal(http.StatusCreated))
+
+		spans := tracer.Spans()
+
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name).To(Equal("POST /greet"))
+		Expect(spans[0].Status).To(Equal(http.StatusCreated))
+		Expect(spans[0].Err).NotTo(HaveOccurred())
+		Expect(spans[0].EndTime.IsZero()).To(BeFalse())
+		Expect(eventNames(spans[0])).To(Equal([]string{"read", "write"}))
+	})
+
+	It("records error with matched status", func() {
+		h := func(r *http.Request) (string, error) {
+			return "", &testError{Detail: "oops"}
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		controller.Respond[string](h).
+			With(controller.ErrorWithCode[*testError](http.StatusConflict)).
+			ServeHTTP(w, r)
+
+		spans := tracer.Spans()
+
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status).To(Equal(http.StatusConflict))
+		Expect(spans[0].Err).To(MatchError("oops"))
+	})
+
+	It("records panic with stack", func() {
+		h := func(r *http.Request) (string, error) {
+			panic("boom")
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		controller.Respond[string](h).ServeHTTP(w, r)
+
+		spans := tracer.Spans()
+
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status).To(Equal(http.StatusInternalServerError))
+		Expect(spans[0].Panic).NotTo(BeNil())
+		Expect(spans[0].Panic.Panic).To(Equal("boom"))
+		Expect(spans[0].Panic.Stack).NotTo(BeEmpty())
+	})
+
+	It("continues incoming trace", func() {
+		parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+		var outgoing http.Header
+		h := func(r *http.Request) (string, error) {
+			outgoing = make(http.Header)
+			controller.InjectTraceContext(outgoing, controller.SpanFromContext(r.Context()).SpanContext())
+
+			return "", nil
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("traceparent", parent)
+		r.Header.Set("tracestate", "congo=t61rcWkgMzE")
+
+		controller.Respond[string](h).ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := tracer.Spans()
+
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Parent.String()).To(Equal(parent))
+		Expect(spans[0].Context.TraceID).To(Equal(spans[0].Parent.TraceID))
+		Expect(outgoing.Get("traceparent")).To(Equal(
+			fmt.Sprintf("00-4bf92f3577b34da6a3ce929d0e0e4736-%x-01", spans[0].Context.SpanID),
+		))
+		Expect(outgoing.Get("tracestate")).To(Equal("congo=t61rcWkgMzE"))
+	})
+
+	It("records status written outside of handler", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "success", nil
+		}).With(
+			controller.ETag(),
+			controller.Cache(controller.NewMemoryCacheStore(1<<20), time.Minute),
+		)
+
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		etag := w.Header().Get("ETag")
+
+		action.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("If-None-Match", etag)
+		controller.Respond[string](func(r *http.Request) (string, error) {
+			return "success", nil
+		}).With(controller.ETag()).ServeHTTP(httptest.NewRecorder(), r)
+
+		r = httptest.NewRequest(http.MethodOptions, "/", nil)
+		r.Header.Set("Origin", "https://example.org")
+		r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		controller.Respond[string](func(r *http.Request) (string, error) {
+			return "success", nil
+		}).With(controller.CORS(controller.CORSConfig{})).ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := tracer.Spans()
+
+		Expect(spans).To(HaveLen(4))
+		Expect(spans[1].Status).To(Equal(http.StatusOK))
+		Expect(eventNames(spans[1])).To(BeEmpty())
+		Expect(spans[2].Status).To(Equal(http.StatusNotModified))
+		Expect(spans[3].Status).To(Equal(http.StatusNoContent))
+	})
+
+	It("records read event of Handle", func() {
+		h := func(r *http.Request, in string) (string, error) {
+			return in, nil
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`"Hello World"`))
+		controller.Handle[string, string](h).ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := tracer.Spans()
+
+		Expect(spans).To(HaveLen(1))
+		Expect(eventNames(spans[0])).To(Equal([]string{"read", "write"}))
+	})
+
+	It("ignores malformed traceparent", func() {
+		h := make(http.Header)
+		h.Set("traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+
+		Expect(controller.ExtractTraceContext(h).IsValid()).To(BeFalse())
+
+		h.Set("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+
+		Expect(controller.ExtractTraceContext(h).IsValid()).To(BeFalse())
+	})
+})
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+)
+
+// UnitOfWork wraps handler call in transaction.
+type UnitOfWork interface {
+	// Begin starts transaction, returned context is passed to handler and to Commit or Rollback,
+	// so it is the place to store transaction for handler to use.
+	Begin(ctx context.Context) (context.Context, error)
+	// Commit is called if handler succeeded with status code below 400 before request context is done.
+	Commit(ctx context.Context) error
+	// Rollback is called if handler returned error, panicked, success code is 400 or above
+	// or request context is done, e.g. after Timeout responded.
+	// ctx is not cancelled together with request.
+	Rollback(ctx context.Context) error
+}
+
+// Transactional begins uow before handler is called,
+// commits it if handler succeeded with status code below 400 before request context is done
+// and rolls it back otherwise.
+// Begin and Commit errors are handled as handler errors, Rollback errors are logged.
+func Transactional(uow UnitOfWork) func(Options) {
+	return func(o Options) { o.UnitOfWork(uow) }
+}
+
+func (opts *options) unitOfWorkHandle(handle handleFunc) handleFunc {
+	if opts.unitOfWork == nil {
+		return handle
+	}
+
+	uow := opts.unitOfWork
+
+	return func(r *http.Request) (result any, err error) {
+		ctx, err := uow.Begin(r.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if rp := recover(); rp != nil {
+				rollback(r, uow, ctx)
+
+				recovered, ok := rp.(*RecoveredError)
+				if !ok {
+					recovered = newRecoveredError(rp, debug.Stack())
+				}
+
+				panic(recovered)
+			}
+		}()
+
+		result, err = handle(r.WithContext(ctx))
+		if err != nil || opts.successCode >= http.StatusBadRequest {
+			rollback(r, uow, ctx)
+			return result, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			rollback(r, uow, ctx)
+			return nil, err
+		}
+
+		if err := uow.Commit(ctx); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+}
+
+func rollback(r *http.Request, uow UnitOfWork, ctx context.Context) {
+	if err := uow.Rollback(context.WithoutCancel(ctx)); err != nil {
+		logger().Error("failed to roll back unit of work", "route", r.Pattern, "error", err)
+	}
+}
@@ -0,0 +1,134 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testTxKey struct{}
+
+type testUnitOfWork struct {
+	commitErr error
+	calls     []string
+	finished  chan string
+}
+
+func (uow *testUnitOfWork) Begin(ctx context.Context) (context.Context, error) {
+	uow.calls = append(uow.calls, "begin")
+	return context.WithValue(ctx, testTxKey{}, "tx"), nil
+}
+
+func (uow *testUnitOfWork) Commit(ctx context.Context) error {
+	uow.calls = append(uow.calls, "commit "+ctx.Value(testTxKey{}).(string))
+	uow.finish("commit")
+
+	return uow.commitErr
+}
+
+func (uow *testUnitOfWork) Rollback(ctx context.Context) error {
+	uow.calls = append(uow.calls, "rollback "+ctx.Value(testTxKey{}).(string))
+	uow.finish("rollback")
+
+	return nil
+}
+
+func (uow *testUnitOfWork) finish(call string) {
+	if uow.finished != nil {
+		uow.finished <- call
+	}
+}
+
+var _ = Describe("Transactional", func() {
+	var uow *testUnitOfWork
+
+	BeforeEach(func() {
+		uow = &testUnitOfWork{}
+	})
+
+	serve := func(action http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		action.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		return w
+	}
+
+	It("commits if handler succeeded", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return r.Context().Value(testTxKey{}).(string), nil
+		}).With(controller.Transactional(uow))
+
+		w := serve(action)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("\"tx\"\n"))
+		Expect(uow.calls).To(Equal([]string{"begin", "commit tx"}))
+	})
+
+	It("rolls back if handler failed", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "", &testError{Detail: "invalid"}
+		}).With(
+			controller.Transactional(uow),
+			controller.ErrorWithCode[*testError](http.StatusBadRequest),
+		)
+
+		Expect(serve(action).Code).To(Equal(http.StatusBadRequest))
+		Expect(uow.calls).To(Equal([]string{"begin", "rollback tx"}))
+	})
+
+	It("rolls back if handler panicked", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			panic("boom")
+		}).With(controller.Transactional(uow))
+
+		Expect(serve(action).Code).To(Equal(http.StatusInternalServerError))
+		Expect(uow.calls).To(Equal([]string{"begin", "rollback tx"}))
+	})
+
+	It("rolls back if success code is not below 400", func() {
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "teapot", nil
+		}).With(
+			controller.Transactional(uow),
+			controller.SuccessCode(http.StatusTeapot),
+		)
+
+		Expect(serve(action).Code).To(Equal(http.StatusTeapot))
+		Expect(uow.calls).To(Equal([]string{"begin", "rollback tx"}))
+	})
+
+	It("maps commit error through error handlers", func() {
+		uow.commitErr = &testError{Detail: "serialization failure"}
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			return "ok", nil
+		}).With(
+			controller.Transactional(uow),
+			controller.ErrorWithCode[*testError](http.StatusConflict),
+		)
+
+		Expect(serve(action).Code).To(Equal(http.StatusConflict))
+		Expect(uow.calls).To(Equal([]string{"begin", "commit tx"}))
+	})
+
+	It("rolls back if handler finished after timeout", func() {
+		uow.finished = make(chan string, 1)
+		action := controller.Respond[string](func(r *http.Request) (string, error) {
+			<-r.Context().Done()
+			return "late", nil
+		}).With(
+			controller.Transactional(uow),
+			controller.Timeout(10*time.Millisecond),
+		)
+
+		Expect(serve(action).Code).To(Equal(http.StatusServiceUnavailable))
+		Eventually(uow.finished).Should(Receive(Equal("rollback")))
+		Expect(uow.calls).To(Equal([]string{"begin", "rollback tx"}))
+	})
+})
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// If requested API version is not supported by endpoint - UnsupportedVersionError is returned.
+type UnsupportedVersionError struct {
+	Version   string
+	Supported []string
+}
+
+func (err *UnsupportedVersionError) Error() string {
+	if err.Version == "" {
+		return fmt.Sprintf("API version is required, supported versions: %s", strings.Join(err.Supported, ", "))
+	}
+
+	return fmt.Sprintf("API version %q is not supported, supported versions: %s", err.Version, strings.Join(err.Supported, ", "))
+}
+
+// VersionConfig configures how API version is resolved from request.
+// Version is looked up in path value, then in header, then in version parameter of Accept media type.
+// Versions are compared case-insensitively with leading "v" trimmed, so "v2" and "2" are the same version.
+type VersionConfig struct {
+	// PathValue is name of pattern wildcard holding version, e.g. "version" for "GET /{version}/users".
+	// Path is not used if empty.
+	PathValue string
+	// Header is request header holding version.
+	// "API-Version" is used if empty.
+	Header string
+	// Default is version used if request does not specify one.
+	// Requests without version are rejected if empty.
+	Default string
+}
+
+type versionKey struct{}
+
+// APIVersion returns API version resolved for request by Versions endpoint.
+func APIVersion(ctx context.Context) string {
+	version, _ := ctx.Value(versionKey{}).(string)
+	return version
+}
+
+// Versions is Endpoint that serves request with endpoint registered for requested API version.
+// Resolved version is available to endpoints with APIVersion and is returned in response header.
+// Request for unknown version is rejected with *UnsupportedVersionError (400 Bad Request unless matched otherwise).
+func Versions(config VersionConfig, endpoints map[string]Endpoint) Endpoint {
+	if config.Header == "" {
+		config.Header = "API-Version"
+	}
+
+	normalized := make(map[string]Endpoint, len(endpoints))
+	for version, endpoint := range endpoints {
+		normalized[normalizeVersion(version)] = endpoint
+	}
+
+	return versions{config: config, endpoints: normalized}
+}
+
+type versions struct {
+	config    VersionConfig
+	endpoints map[string]Endpoint
+}
+
+func (v versions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.getHttpHandle(newOptions()).ServeHTTP(w, r)
+}
+
+// getHttpHandle resolves version before request enters single handler pipeline shared by all versions,
+// so that Cache, MaxInFlight, RateLimit and other options apply to route rather than to version.
+func (v versions) getHttpHandle(opts *options) http.Handler {
+	h := opts.handler(v.getHandleFunc(opts))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", v.config.Header)
+		w.Header().Add("Vary", "Accept")
+
+		version, err := v.resolve(r)
+		if err != nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(v.config.Header, version)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+	})
+}
+
+// getHandleFunc dispatches request to handle of resolved version.
+func (v versions) getHandleFunc(opts *options) handleFunc {
+	handles := make(map[string]handleFunc, len(v.endpoints))
+	for version, endpoint := range v.endpoints {
+		handles[version] = endpoint.getHandleFunc(opts)
+	}
+
+	return func(r *http.Request) (any, error) {
+		version, err := v.resolve(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return handles[version](r)
+	}
+}
+
+func (v versions) resolve(r *http.Request) (string, error) {
+	version := ""
+	if v.config.PathValue != "" {
+		version = r.PathValue(v.config.PathValue)
+	}
+
+	if version == "" {
+		version = r.Header.Get(v.config.Header)
+	}
+
+	if version == "" {
+		version = acceptVersion(r.Header.Get("Accept"))
+	}
+
+	if version == "" {
+		version = v.config.Default
+	}
+
+	version = normalizeVersion(version)
+	if _, ok := v.endpoints[version]; !ok {
+		supported := make([]string, 0, len(v.endpoints))
+		for version := range v.endpoints {
+			supported = append(supported, version)
+		}
+
+		slices.Sort(supported)
+
+		return "", &UnsupportedVersionError{Version: version, Supported: supported}
+	}
+
+	return version, nil
+}
+
+func acceptVersion(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(mediaType)
+		if err == nil && params["version"] != "" {
+			return params["version"]
+		}
+	}
+
+	return ""
+}
+
+func normalizeVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	return strings.TrimPrefix(version, "v")
+}
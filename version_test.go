@@ -0,0 +1,126 @@
+// nolint: typecheck
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/andriiyaremenko/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Versions", func() {
+	var router *controller.Router
+
+	respond := func(name string) controller.Respond[string] {
+		return func(r *http.Request) (string, error) {
+			return name + " " + controller.APIVersion(r.Context()), nil
+		}
+	}
+
+	BeforeEach(func() {
+		endpoints := map[string]controller.Endpoint{
+			"v1": respond("first"),
+			"v2": respond("second"),
+			"v3": respond("third"),
+		}
+
+		router = controller.NewRouter()
+		router.Register("GET /users", controller.Versions(controller.VersionConfig{Default: "v3"}, endpoints))
+		router.Register("GET /{version}/orders", controller.Versions(controller.VersionConfig{PathValue: "version"}, endpoints))
+	})
+
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, values := range header {
+			r.Header[name] = values
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w
+	}
+
+	It("selects version from header", func() {
+		w := serve("/users", http.Header{"Api-Version": {"2"}})
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("\"second 2\"\n"))
+		Expect(w.Header().Get("API-Version")).To(Equal("2"))
+		Expect(w.Header().Values("Vary")).To(ContainElements("API-Version", "Accept"))
+	})
+
+	It("selects version from Accept media type", func() {
+		w := serve("/users", http.Header{"Accept": {"text/html, application/json; version=1"}})
+
+		Expect(w.Body.String()).To(Equal("\"first 1\"\n"))
+	})
+
+	It("selects version from path", func() {
+		Expect(serve("/v2/orders", nil).Body.String()).To(Equal("\"second 2\"\n"))
+	})
+
+	It("uses default version", func() {
+		Expect(serve("/users", nil).Body.String()).To(Equal("\"third 3\"\n"))
+	})
+
+	It("rejects unknown version", func() {
+		w := serve("/v4/orders", nil)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Body.String()).To(Equal("\"API version \\\"4\\\" is not supported, supported versions: 1, 2, 3\"\n"))
+	})
+
+	It("shares options of route between versions", func() {
+		started, release := make(chan struct{}), make(chan struct{})
+		blocking := controller.Respond[string](func(r *http.Request) (string, error) {
+			close(started)
+			<-release
+
+			return "first", nil
+		})
+
+		router.Register("GET /limited", controller.Versions(controller.VersionConfig{}, map[string]controller.Endpoint{
+			"v1": blocking,
+			"v2": respond("second"),
+		}), controller.MaxInFlight(1))
+
+		done := make(chan int, 1)
+		go func() { done <- serve("/limited", http.Header{"Api-Version": {"1"}}).Code }()
+
+		<-started
+
+		Expect(serve("/limited", http.Header{"Api-Version": {"2"}}).Code).To(Equal(http.StatusServiceUnavailable))
+
+		close(release)
+
+		Expect(<-done).To(Equal(http.StatusOK))
+	})
+
+	It("caches responses per version", func() {
+		router.Register("GET /cached", controller.Versions(controller.VersionConfig{Default: "1"}, map[string]controller.Endpoint{
+			"v1": respond("first"),
+			"v2": respond("second"),
+		}), controller.CacheWith(controller.CacheConfig{
+			Store: controller.NewMemoryCacheStore(1 << 20),
+			TTL:   time.Minute,
+			Vary:  []string{"Accept-Language"},
+		}))
+
+		Expect(serve("/cached", nil).Body.String()).To(Equal("\"first 1\"\n"))
+
+		w := serve("/cached", http.Header{"Api-Version": {"2"}})
+
+		Expect(w.Body.String()).To(Equal("\"second 2\"\n"))
+		Expect(w.Header().Values("Vary")).To(ContainElements("API-Version", "Accept", "Accept-Language"))
+
+		w = serve("/cached", http.Header{"Api-Version": {"2"}})
+
+		Expect(w.Header().Get("Age")).To(Equal("0"))
+		Expect(w.Body.String()).To(Equal("\"second 2\"\n"))
+		Expect(w.Header().Values("Vary")).To(ContainElements("API-Version", "Accept", "Accept-Language"))
+	})
+})
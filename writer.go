@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+)
+
+// responseBuffer is http.ResponseWriter that keeps response in memory
+// until it is flushed.
+type responseBuffer struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer(header http.Header) *responseBuffer {
+	return &responseBuffer{header: header}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.code == 0 {
+		b.code = code
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) status() int {
+	if b.code == 0 {
+		return http.StatusOK
+	}
+
+	return b.code
+}
+
+// flush writes buffered response to w.
+func (b *responseBuffer) flush(w http.ResponseWriter) {
+	copyHeader(w.Header(), b.header)
+	w.WriteHeader(b.status())
+
+	if b.body.Len() > 0 {
+		if _, err := w.Write(b.body.Bytes()); err != nil {
+			logger().Error("failed to write response", "error", err)
+		}
+	}
+}
+
+// copyHeader replaces dst headers with src ones, except Vary which values are merged,
+// so that Vary set by outer stages is kept.
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		if k != "Vary" {
+			dst[k] = v
+			continue
+		}
+
+		for _, value := range v {
+			if !slices.Contains(dst[k], value) {
+				dst.Add(k, value)
+			}
+		}
+	}
+}